@@ -1,41 +1,61 @@
 package ioc
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"reflect"
+	"runtime/pprof"
+	"time"
 )
 
 var (
-	errorType = reflect.TypeOf(error(nil))
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+	// ErrNilFactoryResult 返回接口的工厂函数返回了 nil 接口，且没有返回错误
+	ErrNilFactoryResult = errors.New("ioc: factory returned a nil interface")
 
 	errNotFactory        = errors.New("ioc: the factory must be a function")
 	errInvalidFactory    = errors.New("ioc: factory function signature is invalid - it must return abstract, or abstract and error")
 	errCircularReference = errors.New("ioc: factory function signature is invalid - depends on abstract it returns")
+	errFactoryTimeout    = errors.New("ioc: factory timed out")
 )
 
 type binding struct {
-	name    string
-	typ     reflect.Type
-	factory reflect.Value
-	shared  bool
+	name          string
+	typ           reflect.Type
+	factory       reflect.Value
+	shared        bool
+	ttl           time.Duration                             // 共享实例的有效期，零值表示永不过期
+	timeout       time.Duration                             // 单次执行的时长限制，零值表示不限制
+	ifaceOnly     bool                                      // 只能通过接口获取，不能直接获取其返回值类型
+	finalizer     bool                                      // 为非共享的实例设置调用 Close 方法的终结器
+	spread        bool                                      // 返回切片的值组工厂函数，关闭的是其中的每个元素
+	provider      func(c *Container) (reflect.Value, error) // 类型化的构造函数，不通过反射调用
+	closePriority int                                       // 容器关闭时共享实例的关闭优先级
+	instance      reflect.Value                             // 缓存的共享实例
+	created       time.Time                                 // 共享实例的构建时间
 }
 
-func newBinding(name string, factory any, shared ...bool) (*binding, error) {
+func newBinding(name string, factory any, opts ...FactoryOption) (*binding, error) {
 	rv := reflect.ValueOf(factory)
-	rt := rv.Type()
-	if rt.Kind() != reflect.Func {
+	if rv.Kind() != reflect.Func {
 		return nil, errNotFactory
 	}
+	rt := rv.Type()
 	switch returnCount := rt.NumOut(); returnCount {
+	case 0:
+		return nil, fmt.Errorf("%w: %v returns nothing", errInvalidFactory, rt)
 	case 1:
 		// 只有一个返回值
 	case 2:
 		// 第二个返回值必须实现 error 接口
 		if !rt.Out(1).Implements(errorType) {
-			return nil, errInvalidFactory
+			return nil, fmt.Errorf("%w: return value 2 (%v) of %v is not an error", errInvalidFactory, rt.Out(1), rt)
 		}
 	default:
-		return nil, errInvalidFactory
+		// 不支持一个工厂函数同时提供多个值
+		return nil, fmt.Errorf("%w: %v returns %d values, multiple provided values are not supported", errInvalidFactory, rt, returnCount)
 	}
 	concreteType := rt.Out(0)
 	// 检查是否循环引用
@@ -49,32 +69,209 @@ func newBinding(name string, factory any, shared ...bool) (*binding, error) {
 		typ:     concreteType,
 		factory: rv,
 	}
-	if len(shared) > 0 {
-		b.shared = shared[0]
+	for _, opt := range opts {
+		opt(b)
 	}
 	return b, nil
 }
 
-func (b *binding) make(c *Container) (reflect.Value, error) {
-	if values, exists := c.instances[b.typ]; exists {
-		v, ok := values[b.name]
-		if ok {
-			return v, nil
+func (b *binding) make(ctx context.Context, c *Container) (reflect.Value, error) {
+	if b.shared && b.instance.IsValid() && !b.expired() {
+		c.hits.Add(1)
+		return b.instance, nil
+	}
+	c.misses.Add(1)
+	rv, err := b.build(ctx, c)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if b.shared && rv.IsValid() {
+		if b.instance.IsValid() {
+			// 过期的共享实例被替换，关闭它而不是等到容器关闭，
+			// 关闭失败不影响新实例的使用
+			for _, v := range b.closeables(b.instance) {
+				_ = c.releaseCloser(v)
+			}
+		}
+		b.instance = rv
+		b.created = time.Now()
+		for _, v := range b.closeables(rv) {
+			c.addCloser(v, b.closePriority)
+		}
+		if c.opts.onConstruct != nil {
+			c.opts.onConstruct(b.typ, b.name, rv)
+		}
+	} else if b.finalizer && rv.IsValid() {
+		for _, v := range b.closeables(rv) {
+			setFinalizer(v)
+		}
+	}
+	return rv, nil
+}
+
+// build 执行工厂函数构建一个新的实例，不涉及共享实例的缓存
+func (b *binding) build(ctx context.Context, c *Container) (_ reflect.Value, err error) {
+	if start := c.opts.spanStarter; start != nil {
+		// 返回的上下文将被传入工厂函数，以便在其中创建子 span
+		var finish func(error)
+		ctx, finish = start(ctx, b.typ.String())
+		defer func() { finish(err) }()
+	}
+	// 只有工厂函数自身的时长限制到期才视为超时，父上下文的截止时间到期时
+	// 返回工厂函数自身的结果
+	var timeoutCtx context.Context
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, b.timeout, errFactoryTimeout)
+		defer cancel()
+		timeoutCtx = ctx
+	}
+	// 参数对象中使用 `ioc:",name"` 标签的字段将被注入工厂函数绑定的名称
+	ctx = context.WithValue(ctx, nameKey, b.name)
+	var val []reflect.Value
+	if c.opts.profileLabels {
+		// 为执行工厂函数的 goroutine 设置 pprof 标签，以便在性能剖析中区分各个工厂函数
+		pprof.Do(ctx, pprof.Labels("ioc.type", b.typ.String()), func(ctx context.Context) {
+			val, err = b.call(ctx, c)
+		})
+	} else {
+		val, err = b.call(ctx, c)
+	}
+	if timeoutCtx != nil && context.Cause(timeoutCtx) == errFactoryTimeout {
+		if err == nil && (len(val) == 1 || val[1].IsNil()) {
+			// 超时之后才返回的实例不会被使用，也就不会被容器关闭，需要立即关闭
+			closeValue(val[0])
 		}
+		return reflect.Value{}, fmt.Errorf("ioc: factory %v timed out after %v: %w", b.factory.Type(), b.timeout, context.DeadlineExceeded)
 	}
-	val, err := c.invoke(b.factory.Type(), b.factory)
 	if err != nil {
 		return reflect.Value{}, err
 	}
-	rv := val[0]
-	if len(val) == 2 {
-		err = val[1].Interface().(error)
+	if len(val) == 2 && !val[1].IsNil() {
+		return reflect.Value{}, val[1].Interface().(error)
+	}
+	// 返回接口的工厂函数返回 nil 时没有任何“具体实现”可以注入，视为错误；
+	// 而 nil 指针是声明类型的合法值（与绑定 nil 指针一致），原样返回
+	if out := val[0]; out.Kind() == reflect.Interface && out.IsNil() {
+		return reflect.Value{}, fmt.Errorf("%w: %v", ErrNilFactoryResult, b.factory.Type())
+	}
+	return val[0], nil
+}
+
+// closeables 返回实例 rv 中需要关闭的值，对于返回切片的值组工厂函数是切片中的
+// 每个元素，切片本身不会被关闭
+func (b *binding) closeables(rv reflect.Value) []reflect.Value {
+	if !b.spread {
+		return []reflect.Value{rv}
+	}
+	values := make([]reflect.Value, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i)
+	}
+	return values
+}
+
+// call 执行工厂函数，类型化的构造函数直接调用，不经过反射
+func (b *binding) call(ctx context.Context, c *Container) ([]reflect.Value, error) {
+	if b.provider != nil {
+		val, err := b.provider(c)
 		if err != nil {
-			return reflect.Value{}, err
+			return nil, err
 		}
+		return []reflect.Value{val}, nil
 	}
-	if b.shared && rv.IsValid() {
-		c.setInstance(b.name, b.typ, rv)
+	return c.invoke(ctx, b.factory.Type(), b.factory, invokeOptions{})
+}
+
+// concreteType 返回工厂函数构建的实例的实际类型，尚未构建共享实例时
+// 返回工厂函数声明的返回值类型
+func (b *binding) concreteType() reflect.Type {
+	if b.instance.IsValid() {
+		return concreteType(b.instance)
 	}
-	return rv, nil
+	return b.typ
+}
+
+// reachableAs 判断是否可以通过类型 t 获取工厂函数构建的实例
+func (b *binding) reachableAs(t reflect.Type) bool {
+	return !b.ifaceOnly || t.Kind() == reflect.Interface
+}
+
+// hidden 判断类型 t 与名称 name 是否被只能通过接口获取的工厂函数占用，
+// 此时不能直接获取，也不能通过自动构建绕过。
+func (c *Container) hidden(name string, t reflect.Type) bool {
+	for _, ci := range c.lineage() {
+		if b, ok := ci.factories[t][name]; ok && !b.reachableAs(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// stable 判断构建的实例是否可以被重复使用，即共享且永不过期
+func (b *binding) stable() bool {
+	return b.shared && b.ttl == 0
+}
+
+// expired 判断缓存的共享实例是否已经过期
+func (b *binding) expired() bool {
+	return b.ttl > 0 && time.Since(b.created) >= b.ttl
+}
+
+// BindingInfo 描述一个绑定，可以是绑定的值，也可以是工厂函数
+type BindingInfo struct {
+	Type    reflect.Type // 绑定的类型，对于工厂函数是其返回值的类型
+	Name    string       // 绑定的名称
+	Factory bool         // 是否为工厂函数
+	Shared  bool         // 工厂函数构建的实例是否共享
+}
+
+// bindings 返回容器中所有的绑定，按照类型与名称排序，值在工厂函数之前
+func (c *Container) bindings() []BindingInfo {
+	var result []BindingInfo
+	types := make([]reflect.Type, 0, len(c.instances))
+	for rt := range c.instances {
+		types = append(types, rt)
+	}
+	sortTypes(types)
+	for _, rt := range types {
+		for _, name := range sortedKeys(c.instances[rt]) {
+			result = append(result, BindingInfo{Type: rt, Name: name})
+		}
+	}
+	types = types[:0]
+	for rt := range c.factories {
+		types = append(types, rt)
+	}
+	sortTypes(types)
+	for _, rt := range types {
+		bindings := c.factories[rt]
+		for _, name := range sortedKeys(bindings) {
+			result = append(result, BindingInfo{
+				Type:    rt,
+				Name:    name,
+				Factory: true,
+				Shared:  bindings[name].shared,
+			})
+		}
+	}
+	return result
+}
+
+// BindingsImplementing 返回所有类型能够赋值给 t（或实现了接口 t）的绑定，
+// 参数 inherited 为 true 时同时包含父容器中的绑定。
+func (c *Container) BindingsImplementing(t reflect.Type, inherited ...bool) []BindingInfo {
+	containers := []*Container{c}
+	if len(inherited) > 0 && inherited[0] {
+		containers = c.lineage()
+	}
+	var result []BindingInfo
+	for _, ci := range containers {
+		for _, info := range ci.bindings() {
+			if info.Type.AssignableTo(t) {
+				result = append(result, info)
+			}
+		}
+	}
+	return result
 }
@@ -0,0 +1,598 @@
+package ioc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sharedService struct{ n int }
+type transientService struct{}
+
+func TestOnConstruct(t *testing.T) {
+	events := map[string]int{}
+	c := New(WithOnConstruct(func(t reflect.Type, name string, value reflect.Value) {
+		events[t.String()+"/"+name]++
+	}))
+	if err := c.Factory(func() *sharedService { return &sharedService{} }, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.NamedFactory("other", func() *sharedService { return &sharedService{} }, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Factory(func() *transientService { return &transientService{} }); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		mustGet(t, c, "", reflect.TypeOf(&sharedService{}))
+		mustGet(t, c, "other", reflect.TypeOf(&sharedService{}))
+		mustGet(t, c, "", reflect.TypeOf(&transientService{}))
+	}
+	want := map[string]int{"*ioc.sharedService/": 1, "*ioc.sharedService/other": 1}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("got events %v, want %v", events, want)
+	}
+}
+
+// mustGet 获取指定类型与名称的值，失败时终止测试
+func mustGet(t *testing.T, c *Container, name string, typ reflect.Type) reflect.Value {
+	t.Helper()
+	val, err := c.NamedGet(name, typ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return val
+}
+
+func TestCacheStats(t *testing.T) {
+	c := New()
+	if err := c.Factory(func() *sharedService { return &sharedService{} }, true); err != nil {
+		t.Fatal(err)
+	}
+	typ := reflect.TypeOf(&sharedService{})
+	mustGet(t, c, "", typ)
+	if hits, misses := c.CacheStats(); hits != 0 || misses != 1 {
+		t.Fatalf("after first get: hits=%d misses=%d, want 0 and 1", hits, misses)
+	}
+	mustGet(t, c, "", typ)
+	if hits, misses := c.CacheStats(); hits != 1 || misses != 1 {
+		t.Fatalf("after second get: hits=%d misses=%d, want 1 and 1", hits, misses)
+	}
+}
+
+func TestResetType(t *testing.T) {
+	c := New()
+	calls := 0
+	if err := c.Factory(func() *sharedService { calls++; return &sharedService{n: calls} }, true); err != nil {
+		t.Fatal(err)
+	}
+	typ := reflect.TypeOf(&sharedService{})
+	first := mustGet(t, c, "", typ).Interface().(*sharedService)
+	mustGet(t, c, "", typ)
+	if calls != 1 {
+		t.Fatalf("factory ran %d times before reset, want 1", calls)
+	}
+	c.ResetType(typ)
+	second := mustGet(t, c, "", typ).Interface().(*sharedService)
+	if calls != 2 || first == second {
+		t.Fatalf("factory ran %d times after reset, want 2 with a new instance", calls)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	c := New()
+	calls := 0
+	if err := c.FactoryWith(func() *sharedService { calls++; return &sharedService{n: calls} }, WithTTL(20*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	typ := reflect.TypeOf(&sharedService{})
+	first := mustGet(t, c, "", typ)
+	if second := mustGet(t, c, "", typ); first.Pointer() != second.Pointer() || calls != 1 {
+		t.Fatal("expected a cache hit before the TTL expires")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if third := mustGet(t, c, "", typ); first.Pointer() == third.Pointer() || calls != 2 {
+		t.Fatal("expected reconstruction after the TTL expires")
+	}
+}
+
+func TestTTLClosesExpiredInstance(t *testing.T) {
+	c := New()
+	var log []string
+	calls := 0
+	if err := c.FactoryWith(func() *trackedCloser {
+		calls++
+		return &trackedCloser{name: fmt.Sprint(calls), log: &log}
+	}, WithTTL(20*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	typ := typeOf[*trackedCloser]()
+	mustGet(t, c, "", typ)
+	time.Sleep(30 * time.Millisecond)
+	mustGet(t, c, "", typ)
+	if !reflect.DeepEqual(log, []string{"1"}) {
+		t.Fatalf("closed %v after the rebuild, want the expired instance", log)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(log, []string{"1", "2"}) {
+		t.Fatalf("closed %v, want each instance closed once", log)
+	}
+}
+
+type freshGreeter interface{ Greet() string }
+
+type countingGreeter struct{ n int }
+
+func (g *countingGreeter) Greet() string { return "hi" }
+
+func TestFreshTag(t *testing.T) {
+	c := New()
+	calls := 0
+	if err := c.Factory(func() *countingGreeter { calls++; return &countingGreeter{n: calls} }, true); err != nil {
+		t.Fatal(err)
+	}
+	var s struct {
+		A *countingGreeter
+		B *countingGreeter `ioc:",fresh"`
+		C freshGreeter
+		D freshGreeter `ioc:",fresh"`
+	}
+	if err := c.Resolve(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.A == s.B {
+		t.Fatal("fresh field received the shared instance")
+	}
+	if s.C != freshGreeter(s.A) {
+		t.Fatal("interface field should receive the shared instance")
+	}
+	if s.D == freshGreeter(s.A) {
+		t.Fatal("fresh interface field served by the type scan received the shared instance")
+	}
+	// 新构建的实例不会替换缓存的共享实例
+	if got := mustGet(t, c, "", reflect.TypeOf(&countingGreeter{})).Interface(); got != s.A {
+		t.Fatal("fresh construction replaced the cached instance")
+	}
+	if calls != 3 {
+		t.Fatalf("factory ran %d times, want 3", calls)
+	}
+}
+
+func TestBindingsImplementing(t *testing.T) {
+	parent := New()
+	parent.Bind(&trackedCloser{name: "parent"})
+	c := parent.Fork()
+	c.Bind(&trackedCloser{name: "a"})
+	c.NamedBind("b", &trackedCloser{name: "b"})
+	c.Bind(&sharedService{})
+	if err := c.NamedFactory("f", func() *trackedCloser { return &trackedCloser{} }); err != nil {
+		t.Fatal(err)
+	}
+	closer := reflect.TypeOf((*io.Closer)(nil)).Elem()
+	got := c.BindingsImplementing(closer)
+	want := []BindingInfo{
+		{Type: typeOf[*trackedCloser](), Name: ""},
+		{Type: typeOf[*trackedCloser](), Name: "b"},
+		{Type: typeOf[*trackedCloser](), Name: "f", Factory: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got := c.BindingsImplementing(closer, true); len(got) != 4 {
+		t.Fatalf("got %d bindings including parents, want 4", len(got))
+	}
+}
+
+func TestFactorySignatureErrors(t *testing.T) {
+	c := New()
+	cases := []struct {
+		factory any
+		want    string
+	}{
+		{func() (*sharedService, *transientService) { return nil, nil }, "return value 2 (*ioc.transientService)"},
+		{func() (*sharedService, *transientService, error) { return nil, nil, nil }, "returns 3 values"},
+		{func() {}, "returns nothing"},
+		{42, "must be a function"},
+	}
+	for _, tc := range cases {
+		err := c.Factory(tc.factory)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%T: got %v, want an error containing %q", tc.factory, err, tc.want)
+		}
+	}
+	for _, factory := range []any{
+		func() *sharedService { return nil },
+		func() (*transientService, error) { return nil, nil },
+	} {
+		if err := c.Factory(factory); err != nil {
+			t.Errorf("%T: %v", factory, err)
+		}
+	}
+}
+
+type dbConfig struct {
+	Host string `ioc:"db_host"`
+	Port int    `ioc:"db_port"`
+}
+
+type database struct{ addr string }
+
+func TestFactoryConfigStructParameter(t *testing.T) {
+	c := New()
+	c.NamedBind("db_host", "localhost")
+	c.NamedBind("db_port", 5432)
+	if err := c.Factory(func(cfg dbConfig) *database {
+		return &database{addr: fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	db := mustGet(t, c, "", typeOf[*database]()).Interface().(*database)
+	if db.addr != "localhost:5432" {
+		t.Fatalf("got %q", db.addr)
+	}
+}
+
+func TestFactoryNilResult(t *testing.T) {
+	c := New()
+	if err := c.Factory(func() *sharedService { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Factory(func() greeter { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	// nil 指针是声明类型的合法值
+	if v := mustGet(t, c, "", typeOf[*sharedService]()); !v.IsNil() {
+		t.Fatalf("got %v, want a nil pointer", v)
+	}
+	_, err := c.Get(typeOf[greeter]())
+	if !errors.Is(err, ErrNilFactoryResult) || !strings.Contains(err.Error(), "func() ioc.greeter") {
+		t.Fatalf("got %v, want ErrNilFactoryResult naming the factory", err)
+	}
+}
+
+func TestFactoryChanAndFuncResults(t *testing.T) {
+	c := New()
+	if err := c.Factory(func() chan int { return make(chan int, 1) }, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Factory(func() func() string { return func() string { return "ok" } }); err != nil {
+		t.Fatal(err)
+	}
+	ch := mustGet(t, c, "", reflect.TypeOf((*chan int)(nil)).Elem())
+	if again := mustGet(t, c, "", reflect.TypeOf((*chan int)(nil)).Elem()); again.Pointer() != ch.Pointer() {
+		t.Fatal("the shared channel was built twice")
+	}
+	fn := mustGet(t, c, "", reflect.TypeOf((*func() string)(nil)).Elem()).Interface().(func() string)
+	if fn() != "ok" {
+		t.Fatalf("got %q", fn())
+	}
+
+	// nil 通道与 nil 函数是合法的值，而不是找不到
+	c = New()
+	if err := c.Factory(func() chan string { return nil }, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Factory(func() func() { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if v := mustGet(t, c, "", reflect.TypeOf((*chan string)(nil)).Elem()); !v.IsNil() {
+		t.Fatalf("got %v, want a nil channel", v)
+	}
+	if v := mustGet(t, c, "", reflect.TypeOf((*func())(nil)).Elem()); !v.IsNil() {
+		t.Fatal("want a nil func")
+	}
+}
+
+func TestBindMany(t *testing.T) {
+	c := New()
+	c.BindMany(map[string]any{
+		"primary": &database{addr: "primary"},
+		"replica": &database{addr: "replica"},
+		"timeout": 3 * time.Second,
+	})
+	for _, name := range []string{"primary", "replica"} {
+		if db := mustGet(t, c, name, typeOf[*database]()).Interface().(*database); db.addr != name {
+			t.Fatalf("%s: got %q", name, db.addr)
+		}
+	}
+	if d := mustGet(t, c, "timeout", typeOf[time.Duration]()).Interface(); d != 3*time.Second {
+		t.Fatalf("got %v", d)
+	}
+}
+
+func TestPerNameSharedFlag(t *testing.T) {
+	c := New()
+	factory := func() *sharedService { return &sharedService{} }
+	if err := c.NamedFactory("cached", factory, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.NamedFactory("fresh", factory); err != nil {
+		t.Fatal(err)
+	}
+	typ := typeOf[*sharedService]()
+	if a, b := mustGet(t, c, "cached", typ), mustGet(t, c, "cached", typ); a.Pointer() != b.Pointer() {
+		t.Fatal("cached returned distinct instances")
+	}
+	if a, b := mustGet(t, c, "fresh", typ), mustGet(t, c, "fresh", typ); a.Pointer() == b.Pointer() {
+		t.Fatal("fresh returned the same instance")
+	}
+	if a, b := mustGet(t, c, "cached", typ), mustGet(t, c, "fresh", typ); a.Pointer() == b.Pointer() {
+		t.Fatal("fresh returned the cached instance")
+	}
+}
+
+func TestFactoryTimeout(t *testing.T) {
+	c := New()
+	err := c.FactoryWith(func(ctx context.Context) (*sharedService, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+			return &sharedService{}, nil
+		}
+	}, WithFactoryTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	_, err = c.Get(typeOf[*sharedService]())
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("got %v, want a timeout error", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("the factory was not cancelled by its deadline")
+	}
+	// 在限制时间内完成的工厂函数不受影响
+	if err := c.FactoryWith(func(ctx context.Context) *database { return &database{} }, WithFactoryTimeout(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	mustGet(t, c, "", typeOf[*database]())
+}
+
+func TestFactoryTimeoutParentDeadline(t *testing.T) {
+	c := New()
+	err := c.FactoryWith(func(ctx context.Context) (*sharedService, error) {
+		<-ctx.Done()
+		return nil, fmt.Errorf("gave up: %w", ctx.Err())
+	}, WithFactoryTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 父上下文的截止时间先到期，不是工厂函数超时
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = c.GetContext(ctx, typeOf[*sharedService]())
+	if !errors.Is(err, context.DeadlineExceeded) || strings.Contains(err.Error(), "timed out") || !strings.Contains(err.Error(), "gave up") {
+		t.Fatalf("got %v, want the factory's own error", err)
+	}
+}
+
+func TestFactoryTimeoutClosesLateResult(t *testing.T) {
+	c := New()
+	var log []string
+	err := c.FactoryWith(func() *trackedCloser {
+		time.Sleep(30 * time.Millisecond) // 不响应取消
+		return &trackedCloser{name: "late", log: &log}
+	}, WithFactoryTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(typeOf[*trackedCloser]()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want a timeout error", err)
+	}
+	if !reflect.DeepEqual(log, []string{"late"}) {
+		t.Fatalf("closed %v, want the late result closed", log)
+	}
+}
+
+type rcImpl struct{ id int }
+
+func (rcImpl) Read([]byte) (int, error) { return 0, io.EOF }
+func (rcImpl) Close() error             { return nil }
+func (rcImpl) String() string           { return "rc" }
+
+// greetFunc 以函数实现接口，函数值不可比较
+type greetFunc func() string
+
+func (f greetFunc) Greet() string { return f() }
+
+func TestBindWithInterfaces(t *testing.T) {
+	c := New()
+	c.BindWithInterfaces(rcImpl{id: 1}, (*io.ReadCloser)(nil), (*fmt.Stringer)(nil))
+	for _, typ := range []reflect.Type{typeOf[rcImpl](), typeOf[io.ReadCloser](), typeOf[fmt.Stringer]()} {
+		if _, ok := c.instances[typ][""]; !ok {
+			t.Fatalf("%v was not bound directly", typ)
+		}
+		if v := mustGet(t, c, "", typ); v.Interface().(rcImpl).id != 1 {
+			t.Fatalf("%v: got %v", typ, v)
+		}
+	}
+	// 同一个值以多个类型绑定，扫描时不会被视为多个候选
+	if v := mustGet(t, c, "", typeOf[io.Reader]()); v.Interface().(rcImpl).id != 1 {
+		t.Fatalf("got %v", v)
+	}
+	all, err := c.GetAll(typeOf[io.Closer]())
+	if err != nil || len(all) != 1 {
+		t.Fatalf("got %v, %v, want the value collected once", all, err)
+	}
+	var s struct {
+		Readers []io.Reader
+	}
+	if err := c.Resolve(&s); err != nil || len(s.Readers) != 1 {
+		t.Fatalf("got %v, %v, want the value injected once", s.Readers, err)
+	}
+	if rt, ok := c.ResolvedType("", typeOf[io.Reader]()); !ok || rt != typeOf[rcImpl]() {
+		t.Fatalf("got %v, %v", rt, ok)
+	}
+
+	// 不同的值依然是有歧义的
+	c.NamedBind("", rcImpl{id: 2})
+	if _, err := c.Get(typeOf[io.Reader]()); !errors.Is(err, ErrAmbiguousBinding) {
+		t.Fatalf("got %v, want ErrAmbiguousBinding", err)
+	}
+
+	// 不可比较的函数值同样只是一个候选
+	c = New()
+	c.BindWithInterfaces(greetFunc(func() string { return "hi" }), (*greeter)(nil))
+	type politeGreeter interface{ Greet() string }
+	if g := mustGet(t, c, "", typeOf[politeGreeter]()); g.Interface().(politeGreeter).Greet() != "hi" {
+		t.Fatalf("got %v", g)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for an interface the value does not implement")
+		}
+	}()
+	c.BindWithInterfaces(&englishGreeter{}, (*io.Reader)(nil))
+}
+
+func TestAmbiguousFactoryScan(t *testing.T) {
+	c := New()
+	if err := c.Factory(func() *englishGreeter { return &englishGreeter{} }); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Factory(func() *chineseGreeter { return &chineseGreeter{} }); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		_, err := c.Get(typeOf[greeter]())
+		if !errors.Is(err, ErrAmbiguousBinding) {
+			t.Fatalf("got %v, want ErrAmbiguousBinding", err)
+		}
+		if !strings.Contains(err.Error(), "*ioc.chineseGreeter, *ioc.englishGreeter") {
+			t.Fatalf("got %v, want the candidates listed in order", err)
+		}
+	}
+	// 只有一个工厂函数时直接使用
+	c.Unbind("", typeOf[*chineseGreeter]())
+	if g := mustGet(t, c, "", typeOf[greeter]()); g.Interface().(greeter).Greet() != "hello" {
+		t.Fatalf("got %v", g)
+	}
+}
+
+func TestInterfaceOnlyFactory(t *testing.T) {
+	c := New()
+	factory := func() greetFunc { return func() string { return "hidden" } }
+	if err := c.FactoryWith(factory, WithInterfaceOnly()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(typeOf[greetFunc]()); !errors.Is(err, ErrValueNotFound) {
+		t.Fatalf("got %v, want the concrete type to be unreachable", err)
+	}
+	g := mustGet(t, c, "", typeOf[greeter]())
+	if g.Interface().(greeter).Greet() != "hidden" {
+		t.Fatalf("got %v", g)
+	}
+}
+
+type hiddenGreeter struct{ word string }
+
+func (g *hiddenGreeter) Greet() string { return g.word }
+
+func TestInterfaceOnlyHidesStructPointer(t *testing.T) {
+	c := New()
+	factory := func() *hiddenGreeter { return &hiddenGreeter{word: "hidden"} }
+	if err := c.FactoryWith(factory, WithInterfaceOnly()); err != nil {
+		t.Fatal(err)
+	}
+	// 不能通过自动构建得到一个零值的 *hiddenGreeter
+	if _, err := c.Get(typeOf[*hiddenGreeter]()); !errors.Is(err, ErrValueNotFound) {
+		t.Fatalf("got %v, want the concrete type to be unreachable", err)
+	}
+	if rt, ok := c.ResolvedType("", typeOf[*hiddenGreeter]()); ok {
+		t.Fatalf("got %v, want the concrete type to be unresolvable", rt)
+	}
+	if all, err := c.GetAll(typeOf[*hiddenGreeter]()); err != nil || len(all) != 0 {
+		t.Fatalf("got %v, %v, want nothing collected by the concrete type", all, err)
+	}
+	var target struct {
+		Greeters []*hiddenGreeter `ioc:",optional"`
+	}
+	if err := c.Resolve(&target); err != nil {
+		t.Fatal(err)
+	}
+	if len(target.Greeters) != 0 {
+		t.Fatalf("got %v, want nothing injected by the concrete type", target.Greeters)
+	}
+	all, err := c.GetAll(typeOf[greeter]())
+	if err != nil || len(all) != 1 || all[0].Interface().(greeter).Greet() != "hidden" {
+		t.Fatalf("got %v, %v, want the instance collected by its interface", all, err)
+	}
+}
+
+type spanKey struct{}
+
+func TestSpanStarter(t *testing.T) {
+	var started, finished []string
+	errFailed := errors.New("failed")
+	c := New(WithSpanStarter(func(ctx context.Context, typeName string) (context.Context, func(error)) {
+		started = append(started, typeName)
+		return context.WithValue(ctx, spanKey{}, typeName), func(err error) {
+			finished = append(finished, fmt.Sprintf("%s:%v", typeName, err))
+		}
+	}))
+	if err := c.Factory(func(ctx context.Context) *database {
+		span, _ := ctx.Value(spanKey{}).(string)
+		return &database{addr: span}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Factory(func() (*sharedService, error) { return nil, errFailed }); err != nil {
+		t.Fatal(err)
+	}
+	db := mustGet(t, c, "", typeOf[*database]()).Interface().(*database)
+	if db.addr != "*ioc.database" {
+		t.Fatalf("got %q, want the span's context passed to the factory", db.addr)
+	}
+	if _, err := c.Get(typeOf[*sharedService]()); !errors.Is(err, errFailed) {
+		t.Fatalf("got %v", err)
+	}
+	if want := []string{"*ioc.database", "*ioc.sharedService"}; !reflect.DeepEqual(started, want) {
+		t.Fatalf("started %v, want %v", started, want)
+	}
+	if want := []string{"*ioc.database:<nil>", "*ioc.sharedService:failed"}; !reflect.DeepEqual(finished, want) {
+		t.Fatalf("finished %v, want %v", finished, want)
+	}
+}
+
+func TestProfileLabels(t *testing.T) {
+	labelsOf := func(ctx context.Context) map[string]string {
+		labels := map[string]string{}
+		pprof.ForLabels(ctx, func(key, value string) bool {
+			labels[key] = value
+			return true
+		})
+		return labels
+	}
+	var got map[string]string
+	factory := func(ctx context.Context) *database {
+		got = labelsOf(ctx)
+		return &database{}
+	}
+	c := New(WithProfileLabels())
+	if err := c.Factory(factory); err != nil {
+		t.Fatal(err)
+	}
+	mustGet(t, c, "", typeOf[*database]())
+	if want := map[string]string{"ioc.type": "*ioc.database"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got labels %v, want %v", got, want)
+	}
+
+	// 默认不设置标签
+	c = New()
+	if err := c.Factory(factory); err != nil {
+		t.Fatal(err)
+	}
+	mustGet(t, c, "", typeOf[*database]())
+	if len(got) != 0 {
+		t.Fatalf("got labels %v without WithProfileLabels", got)
+	}
+}
@@ -0,0 +1,51 @@
+package ioc
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// bindingGen 所有容器的绑定（包括父容器）发生变化的总次数，子容器缓存从父容器中
+// 获取的值时记录它，之后只要它发生了变化，就认为缓存可能已经失效。相比于逐个
+// 比较父容器链上每个容器的变化次数，这样只需要一次比较，代价是任何容器的变化都
+// 会使所有子容器的缓存失效，而绑定通常只在启动阶段发生变化。
+var bindingGen atomic.Uint64
+
+// noParentCache 禁用父容器查找结果的缓存，仅用于在基准测试中对比缓存的效果
+var noParentCache bool
+
+// touch 标记容器的绑定发生了变化，使子容器中缓存的值失效
+func (c *Container) touch() {
+	c.gen++
+	bindingGen.Add(1)
+}
+
+// cachedValue 获取之前从父容器中获取并缓存的值，
+// 任何容器的绑定发生变化之后，缓存全部失效。
+func (c *Container) cachedValue(name string, t reflect.Type) (reflect.Value, bool) {
+	if c.cache == nil || noParentCache {
+		return reflect.Value{}, false
+	}
+	if c.cacheGen != bindingGen.Load() {
+		c.cache = nil
+		return reflect.Value{}, false
+	}
+	val, ok := c.cache[t][name]
+	return val, ok
+}
+
+// cacheValue 缓存从父容器中获取的值，只缓存值的引用，值的生命周期
+// 依然由父容器管理，子容器关闭时不会释放这些值。
+func (c *Container) cacheValue(name string, t reflect.Type, val reflect.Value) {
+	if noParentCache {
+		return
+	}
+	if gen := bindingGen.Load(); c.cache == nil || c.cacheGen != gen {
+		c.cache = make(map[reflect.Type]map[string]reflect.Value)
+		c.cacheGen = gen
+	}
+	if _, ok := c.cache[t]; !ok {
+		c.cache[t] = make(map[string]reflect.Value)
+	}
+	c.cache[t][name] = val
+}
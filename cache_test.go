@@ -0,0 +1,98 @@
+package ioc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParentLookupCache(t *testing.T) {
+	root := New()
+	calls := 0
+	if err := root.Factory(func() *sharedService { calls++; return &sharedService{n: calls} }, true); err != nil {
+		t.Fatal(err)
+	}
+	child := root.Fork()
+	typ := typeOf[*sharedService]()
+	first := mustGet(t, child, "", typ)
+	if _, ok := child.cachedValue("", typ); !ok {
+		t.Fatal("parent lookup was not cached")
+	}
+	if second := mustGet(t, child, "", typ); first.Pointer() != second.Pointer() || calls != 1 {
+		t.Fatal("cached lookup returned a different instance")
+	}
+
+	// 父容器的绑定变化之后缓存失效
+	root.ResetType(typ)
+	if third := mustGet(t, child, "", typ); first.Pointer() == third.Pointer() || calls != 2 {
+		t.Fatal("cache was not invalidated after the parent changed")
+	}
+
+	// 共享实例依然由父容器管理，子容器关闭时不会释放
+	var log []string
+	if err := root.Factory(func() *trackedCloser { return &trackedCloser{"root", &log} }, true); err != nil {
+		t.Fatal(err)
+	}
+	mustGet(t, child, "", typeOf[*trackedCloser]())
+	mustGet(t, child, "", typeOf[*trackedCloser]())
+	if err := child.Close(); err != nil || len(log) != 0 {
+		t.Fatal("child closed a shared instance owned by the parent")
+	}
+	if err := root.Close(); err != nil || len(log) != 1 {
+		t.Fatalf("root closed %d instances, want 1", len(log))
+	}
+}
+
+func TestParentLookupCacheNotCachingTransients(t *testing.T) {
+	root := New()
+	if err := root.Factory(func() *sharedService { return &sharedService{} }); err != nil {
+		t.Fatal(err)
+	}
+	child := root.Fork()
+	first := mustGet(t, child, "", typeOf[*sharedService]())
+	if second := mustGet(t, child, "", typeOf[*sharedService]()); first.Pointer() == second.Pointer() {
+		t.Fatal("transient instance was cached")
+	}
+}
+
+func TestParentLookupCacheAfterWithParent(t *testing.T) {
+	p1 := New()
+	p1.Bind(&namedLogger{prefix: "p1"})
+	p1.Bind(1)
+	p1.Bind("x")
+	p2 := New()
+	p2.Bind(&namedLogger{prefix: "p2"})
+	p2.Bind(2)
+	child := p1.Fork()
+	typ := reflect.TypeOf(&namedLogger{})
+	if got := mustGet(t, child, "", typ).Interface().(*namedLogger).prefix; got != "p1" {
+		t.Fatalf("got %q, want p1", got)
+	}
+	// 父容器链的绑定变化次数之和不变，但父容器已经被替换
+	child.WithParent(p2)
+	if got := mustGet(t, child, "", typ).Interface().(*namedLogger).prefix; got != "p2" {
+		t.Fatalf("got %q after WithParent, want p2", got)
+	}
+}
+
+func BenchmarkParentLookup(b *testing.B) {
+	root := New()
+	root.Bind(&namedLogger{})
+	c := root
+	for i := 0; i < 8; i++ {
+		c = c.Fork()
+	}
+	typ := typeOf[*namedLogger]()
+	run := func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := c.Get(typ); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.Run("cached", run)
+	b.Run("uncached", func(b *testing.B) {
+		noParentCache = true
+		defer func() { noParentCache = false }()
+		run(b)
+	})
+}
@@ -0,0 +1,118 @@
+package ioc
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"reflect"
+	"runtime"
+	"slices"
+	"syscall"
+)
+
+var closerType = reflect.TypeOf((*io.Closer)(nil)).Elem()
+
+// closer 容器关闭时执行的清理函数及其优先级
+type closer struct {
+	priority int
+	close    func() error
+	instance reflect.Value // 需要关闭的共享实例，清理函数没有
+}
+
+// Close 释放容器，关闭本容器构建的、实现了 io.Closer 接口的共享实例并执行
+// 清理函数，父容器构建的实例不受影响。优先级（见 WithClosePriority 与
+// BindWithCleanupPriority）越高的越先关闭，优先级相同的按照与构建相反的顺序
+// （后进先出）关闭。
+func (c *Container) Close() error {
+	closers := c.closers
+	c.closers = nil
+	slices.Reverse(closers)
+	slices.SortStableFunc(closers, func(a, b closer) int {
+		return cmp.Compare(b.priority, a.priority)
+	})
+	var errs []error
+	for _, cl := range closers {
+		if err := cl.close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RunUntilSignal 阻塞直到收到 sigs 中的任意一个信号或者上下文 ctx 被取消，
+// 然后关闭容器并返回 Close 的结果，适合在 main 函数的最后调用以实现优雅退出，
+// sigs 为空时监听 os.Interrupt 与 syscall.SIGTERM。
+func (c *Container) RunUntilSignal(ctx context.Context, sigs ...os.Signal) error {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ctx, stop := signal.NotifyContext(ctx, sigs...)
+	defer stop()
+	<-ctx.Done()
+	return c.Close()
+}
+
+// Scope 派生出一个子容器并传递给函数 fn，无论 fn 返回错误还是触发 panic，
+// 都会保证子容器的 Close 方法被执行，从而释放在子容器中构建的共享实例，
+// 适合用来描述一个“工作单元”。
+func (c *Container) Scope(fn func(scope *Container) error) (err error) {
+	scope := c.Fork()
+	defer func() {
+		if closeErr := scope.Close(); closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}()
+	return fn(scope)
+}
+
+// addCloser 若值实现了 io.Closer 接口，则在容器关闭时以优先级 priority 关闭它
+func (c *Container) addCloser(rv reflect.Value, priority int) {
+	if isNil(rv) || !rv.Type().Implements(closerType) {
+		return
+	}
+	c.closers = append(c.closers, closer{priority: priority, close: rv.Interface().(io.Closer).Close, instance: rv})
+}
+
+// closeValue 若值实现了 io.Closer 接口，则立即关闭它，返回的错误会被忽略
+func closeValue(rv reflect.Value) {
+	if isNil(rv) {
+		return
+	}
+	if cl, ok := rv.Interface().(io.Closer); ok {
+		_ = cl.Close()
+	}
+}
+
+// releaseCloser 立即关闭通过 addCloser 注册的实例 rv，并将其从容器关闭时
+// 需要关闭的实例中移除，用于替换过期的共享实例。
+func (c *Container) releaseCloser(rv reflect.Value) error {
+	i := slices.IndexFunc(c.closers, func(cl closer) bool {
+		return cl.instance.IsValid() && sameValue(cl.instance, rv)
+	})
+	if i < 0 {
+		return nil
+	}
+	cl := c.closers[i]
+	c.closers = slices.Delete(c.closers, i, i+1)
+	return cl.close()
+}
+
+// setFinalizer 若非共享的实例是实现了 io.Closer 接口的指针，则为其设置一个
+// 在被垃圾回收时调用 Close 方法的终结器，该实例已有的终结器会被替换。rv 必须
+// 指向一次内存分配的起始位置，因此只对通过 WithFinalizer 启用的工厂函数调用。
+func setFinalizer(rv reflect.Value) {
+	if rv.Kind() == reflect.Interface {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || !rv.Type().Implements(closerType) {
+		return
+	}
+	obj := rv.Interface()
+	runtime.SetFinalizer(obj, nil)
+	runtime.SetFinalizer(obj, func(x any) {
+		_ = x.(io.Closer).Close()
+	})
+}
@@ -0,0 +1,198 @@
+package ioc
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// trackedCloser 记录 Close 被调用的顺序
+type trackedCloser struct {
+	name string
+	log  *[]string
+}
+
+func (tc *trackedCloser) Close() error {
+	*tc.log = append(*tc.log, tc.name)
+	return nil
+}
+
+func TestScope(t *testing.T) {
+	var log []string
+	root := New()
+	errFailed := errors.New("failed")
+	err := root.Scope(func(scope *Container) error {
+		if err := scope.Factory(func() *trackedCloser { return &trackedCloser{"scoped", &log} }, true); err != nil {
+			return err
+		}
+		if _, err := scope.Get(reflect.TypeOf(&trackedCloser{})); err != nil {
+			return err
+		}
+		if len(log) != 0 {
+			t.Error("closed before fn returned")
+		}
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("got %v, want the error returned by fn", err)
+	}
+	if len(log) != 1 {
+		t.Fatalf("scoped closer ran %d times, want 1", len(log))
+	}
+}
+
+func TestScopeClosesOnPanic(t *testing.T) {
+	var log []string
+	func() {
+		defer func() { _ = recover() }()
+		_ = New().Scope(func(scope *Container) error {
+			if err := scope.Factory(func() *trackedCloser { return &trackedCloser{"scoped", &log} }, true); err != nil {
+				return err
+			}
+			if _, err := scope.Get(reflect.TypeOf(&trackedCloser{})); err != nil {
+				return err
+			}
+			panic("boom")
+		})
+	}()
+	if len(log) != 1 {
+		t.Fatalf("scoped closer ran %d times after a panic, want 1", len(log))
+	}
+}
+
+// chanCloser 关闭时向通道发送通知
+type chanCloser struct{ closed chan struct{} }
+
+func (cc *chanCloser) Close() error {
+	close(cc.closed)
+	return nil
+}
+
+func TestFinalizers(t *testing.T) {
+	closed := make(chan struct{})
+	c := New()
+	if err := c.FactoryWith(func() *chanCloser { return &chanCloser{closed} }, WithFinalizer()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(reflect.TypeOf(&chanCloser{})); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.After(5 * time.Second)
+	for {
+		runtime.GC()
+		select {
+		case <-closed:
+			return
+		case <-deadline:
+			t.Fatal("the finalizer did not close the transient instance")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestFinalizerIsOptIn(t *testing.T) {
+	// 指向结构体字段的指针不是内存分配的起始位置，为其设置终结器是致命错误，
+	// 因此没有启用 WithFinalizer 的工厂函数不会设置终结器
+	type pair struct {
+		n      int
+		closer chanCloser
+	}
+	c := New()
+	if err := c.Factory(func() *chanCloser {
+		p := &pair{closer: chanCloser{make(chan struct{})}}
+		return &p.closer
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := c.Get(reflect.TypeOf(&chanCloser{})); err != nil {
+			t.Fatal(err)
+		}
+		runtime.GC()
+	}
+}
+
+func TestBindWithCleanup(t *testing.T) {
+	c := New()
+	calls := 0
+	ep := &endpoint{URL: "http://a"}
+	c.BindWithCleanup(ep, func() { calls++ })
+	v, err := c.Get(reflect.TypeOf(ep))
+	if err != nil || v.Interface() != ep {
+		t.Fatalf("got %v, %v", v, err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("cleanup ran %d times, want 1", calls)
+	}
+}
+
+func TestRunUntilSignal(t *testing.T) {
+	var log []string
+	c := New()
+	if err := c.Factory(func() *trackedCloser { return &trackedCloser{"shared", &log} }, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(reflect.TypeOf(&trackedCloser{})); err != nil {
+		t.Fatal(err)
+	}
+	c.BindWithCleanup(&endpoint{}, func() { log = append(log, "cleanup") })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.RunUntilSignal(ctx) }()
+	select {
+	case <-done:
+		t.Fatal("RunUntilSignal returned before the context was cancelled")
+	case <-time.After(20 * time.Millisecond):
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunUntilSignal did not return after cancellation")
+	}
+	if want := []string{"cleanup", "shared"}; !reflect.DeepEqual(log, want) {
+		t.Fatalf("got %v, want %v", log, want)
+	}
+}
+
+func TestClosePriority(t *testing.T) {
+	var log []string
+	c := New()
+	cleanup := func(name string) func() {
+		return func() { log = append(log, name) }
+	}
+	if err := c.NamedFactoryWith("db", func() *trackedCloser { return &trackedCloser{"db", &log} }, Shared(), WithClosePriority(-10)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.NamedFactoryWith("server", func() *trackedCloser { return &trackedCloser{"server", &log} }, Shared(), WithClosePriority(10)); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"db", "server"} {
+		if _, err := c.NamedGet(name, reflect.TypeOf(&trackedCloser{})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.BindWithCleanup(&endpoint{URL: "a"}, cleanup("a"))
+	c.BindWithCleanup(&endpoint{URL: "b"}, cleanup("b"))
+	c.BindWithCleanupPriority(&endpoint{URL: "metrics"}, cleanup("metrics"), 5)
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// 优先级高的先关闭，优先级相同的后进先出
+	want := []string{"server", "metrics", "b", "a", "db"}
+	if !reflect.DeepEqual(log, want) {
+		t.Fatalf("got %v, want %v", log, want)
+	}
+}
@@ -4,43 +4,206 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 var (
 	ErrValueNotFound = errors.New("ioc: value not found")
+	ErrNotFunc       = errors.New("ioc: invoke target is not a function")
+	// ErrMaxDepthExceeded 解析的嵌套深度超过了限制，通常意味着依赖图存在递归
+	ErrMaxDepthExceeded = errors.New("ioc: maximum resolution depth exceeded")
+	// ErrAmbiguousBinding 同一个容器中有多个不同类型的“具体实现”都能满足要获取的类型
+	ErrAmbiguousBinding = errors.New("ioc: ambiguous binding")
 
-	contextKey = struct{ name string }{"ioc"}
-	tagName    = "ioc"
+	contextKey  = struct{ name string }{"ioc"}
+	depthKey    = struct{ name string }{"ioc.depth"}
+	nameKey     = struct{ name string }{"ioc.name"}
+	sourceKey   = struct{ name string }{"ioc.source"}
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	cleanupType = reflect.TypeOf(func() {})
+	tagName     = "ioc"
 )
 
+// NotFoundError 表示容器中找不到指定类型与名称的值，它包装了 ErrValueNotFound，
+// 因此 errors.Is(err, ErrValueNotFound) 依然成立，也可以通过 errors.As
+// 获取具体缺失的类型与名称。
+type NotFoundError struct {
+	Type reflect.Type
+	Name string
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Name != "" {
+		return fmt.Sprintf("ioc: value not found for type %v named %q", e.Type, e.Name)
+	}
+	return fmt.Sprintf("ioc: value not found for type %v", e.Type)
+}
+
+// Unwrap 返回 ErrValueNotFound
+func (e *NotFoundError) Unwrap() error {
+	return ErrValueNotFound
+}
+
 // Container 服务容器
 // TODO(hupeh): 保证并发安全
 type Container struct {
-	parent    *Container
+	opts      options
+	parents   []*Container // 父容器，组合容器可以拥有多个父容器
 	factories map[reflect.Type]map[string]*binding
 	instances map[reflect.Type]map[string]reflect.Value
+	aliases   map[reflect.Type]reflect.Type             // 类型别名，键为别名，值为目标类型
+	contexts  []contextBinding                          // 根据上下文选择的绑定
+	defaults  map[reflect.Type]reflect.Value            // 接口的默认实现
+	groups    map[string][]groupMember                  // 值组，键为值组的名称
+	used      map[usageKey]bool                         // 被使用过的绑定
+	indexed   []reflect.Type                            // 需要建立索引的接口
+	index     map[reflect.Type]interfaceIndex           // 接口到其“具体实现”的类型的索引
+	indexGen  uint64                                    // 索引对应的绑定变化次数
+	closers   []closer                                  // 容器关闭时需要执行的清理函数
+	cache     map[reflect.Type]map[string]reflect.Value // 从父容器中获取的值
+	cacheGen  uint64                                    // 缓存对应的全局绑定变化次数
+	gen       uint64                                    // 绑定变化的次数
+	hits      atomic.Int64                              // 命中实例缓存的次数
+	misses    atomic.Int64                              // 执行工厂函数的次数
 }
 
 // New 新建一个服务容器
-func New() *Container {
-	return &Container{}
+func New(opts ...Option) *Container {
+	c := &Container{}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+	return c
 }
 
 // Fork 派生出一个子容器，该子容器能够通过父子关系远程
 // 使用父容器里面的服务，因此同时可以安全的设置与父容器
-// 一致的服务而不影响父容器。
-func (c *Container) Fork() *Container {
-	return &Container{parent: c}
+// 一致的服务而不影响父容器。子容器继承父容器的配置项，
+// 并可以通过参数 opts 覆盖。
+func (c *Container) Fork(opts ...Option) *Container {
+	child := &Container{opts: c.opts, parents: []*Container{c}}
+	for _, opt := range opts {
+		opt(&child.opts)
+	}
+	return child
+}
+
+// WithParent 设置或替换容器的父容器，用于在容器创建之后才确定其父容器的场景，
+// 若设置后会形成父子关系的环（如 A→B→A）则会触发 panic。
+func (c *Container) WithParent(parent *Container) *Container {
+	if parent == nil {
+		c.parents = nil
+		c.touch()
+		return c
+	}
+	for _, p := range parent.lineage() {
+		if p == c {
+			panic("ioc: cannot set parent, it would create a parent cycle")
+		}
+	}
+	c.parents = []*Container{parent}
+	c.touch()
+	return c
+}
+
+// Compose 创建一个组合容器，该容器本身为空，查找时依次尝试每个父容器，
+// 当多个父容器都能提供同一个“具体实现”时，以排在前面的父容器为准。
+func Compose(parents ...*Container) *Container {
+	return &Container{parents: parents}
+}
+
+// lineage 返回容器自身及其所有祖先容器，顺序与查找顺序一致（深度优先），
+// 同一个容器只会出现一次。
+func (c *Container) lineage() []*Container {
+	var result []*Container
+	seen := make(map[*Container]bool)
+	var walk func(ci *Container)
+	walk = func(ci *Container) {
+		if ci == nil || seen[ci] {
+			return
+		}
+		seen[ci] = true
+		result = append(result, ci)
+		for _, p := range ci.parents {
+			walk(p)
+		}
+	}
+	walk(c)
+	return result
 }
 
 // Bind 绑定一个“具体实现”（实例或原语值），需要注意的是，由于内部
 // 是根据类型与“具体实现”直接建立映射关系的，因此同一种类型最多只会
 // 有一个具体实现。
+//
+// 对于原语值，使用的是值本身的类型，如 time.Duration 的值只会匹配
+// time.Duration 类型的字段，而不会匹配其底层类型 int64。
 func (c *Container) Bind(value any) {
 	c.NamedBind("", value)
 }
 
+// BindBothNames 将“具体实现”同时绑定到名称 name 与匿名绑定上，
+// 适用于主要实现既需要通过名称获取，也需要作为默认值的场景。
+func (c *Container) BindBothNames(name string, value any) {
+	c.NamedBind("", value)
+	c.NamedBind(name, value)
+}
+
+// BindWithCleanup 绑定一个“具体实现”，并在容器关闭（Close）时执行清理函数 cleanup，
+// 适用于手动构建的、持有资源的值。清理函数与共享实例的关闭一样按照后进先出的顺序执行，
+// 并且只会执行一次。
+func (c *Container) BindWithCleanup(value any, cleanup func()) {
+	c.BindWithCleanupPriority(value, cleanup, 0)
+}
+
+// BindWithCleanupPriority 与 BindWithCleanup 相同，但是指定了清理函数的优先级，
+// 容器关闭时优先级越高的越先执行，优先级相同的按照后进先出的顺序执行。
+func (c *Container) BindWithCleanupPriority(value any, cleanup func(), priority int) {
+	c.Bind(value)
+	if cleanup != nil {
+		c.closers = append(c.closers, closer{priority: priority, close: func() error {
+			cleanup()
+			return nil
+		}})
+	}
+}
+
+// MustBind 与 Bind 相同，但值为 nil（包括 nil 指针、接口、函数、映射与切片等）
+// 时触发 panic，避免后续使用时出现难以排查的空指针错误；严格模式（WithStrict）下，
+// 值的类型在本容器中已经绑定过值或工厂函数时同样触发 panic。
+func (c *Container) MustBind(value any) {
+	c.MustNamedBind("", value)
+}
+
+// MustNamedBind 与 NamedBind 相同，但与 MustBind 一样会在值为 nil 以及严格模式下
+// 重复绑定时触发 panic。
+func (c *Container) MustNamedBind(name string, value any) {
+	rt := reflect.TypeOf(value)
+	mustNotNil(rt, value)
+	c.mustNotBound(name, rt)
+	c.NamedBind(name, value)
+}
+
+// mustNotBound 严格模式下，类型 t 与名称 name 在本容器中已经绑定时触发 panic
+func (c *Container) mustNotBound(name string, t reflect.Type) {
+	if !c.opts.strict {
+		return
+	}
+	name = c.canonicalName(name)
+	_, instanced := c.instances[t][name]
+	_, bound := c.factories[t][name]
+	if instanced || bound {
+		panic(fmt.Sprintf("ioc: %v named %q is already bound", t, name))
+	}
+}
+
 // NamedBind 具名绑定一个“具体实现”（实例或原语值），由于这个“具体实现”拥有了
 // 名称，所以不会覆盖掉通过 Bind 方法绑定的“具体实现”，这也能够解决同一种类型
 // 在不同的场景和用途下可以指定不同的“具体实现”，因此我们的结构体可以通过指定 `ioc`
@@ -51,8 +214,48 @@ func (c *Container) NamedBind(name string, value any) {
 	c.setInstance(name, rt, rv)
 }
 
+// BindWithInterfaces 将“具体实现”绑定到其自身的类型上，同时绑定到 ifacePtrs 中的
+// 每个接口上（参数为接口的指针，如 (*MyInterface)(nil)），获取这些接口时可以直接
+// 命中而不需要扫描所有的绑定。值没有实现某个接口时触发 panic。
+func (c *Container) BindWithInterfaces(value any, ifacePtrs ...any) {
+	rv := reflect.ValueOf(value)
+	types := make([]reflect.Type, len(ifacePtrs))
+	for i, ptr := range ifacePtrs {
+		t := InterfaceOf(ptr)
+		if !rv.IsValid() || !rv.Type().Implements(t) {
+			panic(fmt.Sprintf("ioc: value of type %T does not implement %v", value, t))
+		}
+		types[i] = t
+	}
+	c.Bind(value)
+	for _, t := range types {
+		c.setInstance("", t, rv)
+	}
+}
+
+// BindType 将“具体实现”绑定到指定的类型 t 上，通常用来将实例直接注册为
+// 某个接口的实现，值必须能够赋值给类型 t。
+func (c *Container) BindType(t reflect.Type, value any) error {
+	return c.NamedBindType("", t, value)
+}
+
+// NamedBindType 将“具体实现”以指定的名称绑定到类型 t 上，该方法与 BindType 类似。
+func (c *Container) NamedBindType(name string, t reflect.Type, value any) error {
+	rv := reflect.ValueOf(value)
+	if t == nil || !rv.IsValid() || !rv.Type().AssignableTo(t) {
+		return fmt.Errorf("ioc: value of type %T is not assignable to %v", value, t)
+	}
+	c.setInstance(name, t, rv)
+	return nil
+}
+
 // 提示：不能通过第三个参数来推导出第二个参数！！！
 func (c *Container) setInstance(name string, rt reflect.Type, rv reflect.Value) {
+	if rt == nil {
+		// 无类型的 nil 无法作为任何类型的“具体实现”
+		return
+	}
+	name = c.canonicalName(name)
 	if c.instances == nil {
 		c.instances = make(map[reflect.Type]map[string]reflect.Value)
 	}
@@ -60,20 +263,123 @@ func (c *Container) setInstance(name string, rt reflect.Type, rv reflect.Value)
 		c.instances[rt] = make(map[string]reflect.Value)
 	}
 	c.instances[rt][name] = rv
+	c.touch()
+}
+
+// Alias 为类型 target 设置别名 alias，当获取类型 alias 且找不到其“具体实现”时，
+// 将使用类型 target 的“具体实现”，target 必须能够赋值给 alias。别名对派生
+// 出来的子容器同样有效。
+func (c *Container) Alias(alias, target reflect.Type) error {
+	if alias == nil || target == nil || alias == target || !target.AssignableTo(alias) {
+		return fmt.Errorf("ioc: cannot alias %v to %v", alias, target)
+	}
+	if c.aliases == nil {
+		c.aliases = make(map[reflect.Type]reflect.Type)
+	}
+	c.aliases[alias] = target
+	c.touch()
+	return nil
+}
+
+// aliasOf 沿着父容器链查找类型 t 的别名的最终目标类型，没有别名时返回 nil
+func (c *Container) aliasOf(t reflect.Type) reflect.Type {
+	lineage := c.lineage()
+	seen := map[reflect.Type]bool{t: true}
+	var target reflect.Type
+	for {
+		next := t
+		for _, ci := range lineage {
+			if at, ok := ci.aliases[t]; ok {
+				next = at
+				break
+			}
+		}
+		if seen[next] {
+			return target
+		}
+		seen[next] = true
+		target, t = next, next
+	}
+}
+
+// BindDefault 为接口绑定一个默认实现，参数 ifacePtr 为接口的指针，如 (*MyInterface)(nil)。
+// 默认实现只有在直接查找与类型扫描都无法找到“具体实现”时才会被使用，因此任何
+// 显式的绑定都会优先于默认实现，默认实现只参与匿名（名称为空）的查找。
+func (c *Container) BindDefault(ifacePtr any, value any) {
+	t := InterfaceOf(ifacePtr)
+	rv := reflect.ValueOf(value)
+	if !rv.IsValid() || !rv.Type().Implements(t) {
+		panic(fmt.Sprintf("ioc: default value of type %T does not implement %v", value, t))
+	}
+	if c.defaults == nil {
+		c.defaults = make(map[reflect.Type]reflect.Value)
+	}
+	c.defaults[t] = rv
+	c.touch()
+}
+
+// lookupDefault 沿着父容器链查找接口的默认实现
+func (c *Container) lookupDefault(name string, t reflect.Type) (reflect.Value, bool) {
+	if name != "" {
+		return reflect.Value{}, false
+	}
+	for _, ci := range c.lineage() {
+		if val, ok := ci.defaults[t]; ok {
+			return val, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// canonicalName 使用配置的名称解析器规范化绑定名称
+func (c *Container) canonicalName(name string) string {
+	if c.opts.nameResolver != nil {
+		return c.opts.nameResolver(name)
+	}
+	return name
 }
 
 // Factory 绑定一个工厂函数，工厂函数必须返回一个“具体实现”，同时还可以返回一个错误对象
 // 表示构建失败，该方法的实现方式与 Bind 方法类似，同一种类型最多也只会有一个工厂函数。
+//
+// 工厂函数类型为 context.Context 的参数将被注入解析过程所使用的上下文（如通过
+// ResolveContext 或 GetContext 传入的上下文），需要注意共享实例只会使用首次
+// 构建时的上下文。
+//
+// 工厂函数可以返回通道或函数等类型，返回值与其它类型一样被缓存与注入，
+// 返回的 nil 通道与 nil 函数是合法的值，不会被视为找不到；只有返回接口的
+// 工厂函数返回 nil 时才是错误（ErrNilFactoryResult）。
 func (c *Container) Factory(factory any, shared ...bool) error {
 	return c.NamedFactory("", factory, shared...)
 }
 
 // NamedFactory 具名绑定工厂函数，该方法的实现方式与 NamedBind 方法类型。
+//
+// 是否共享是每个名称各自的属性，同一个工厂函数可以以不同的名称多次绑定，
+// 如以 "cached" 共享而以 "fresh" 不共享，各个名称的共享实例也相互独立。
 func (c *Container) NamedFactory(name string, factory any, shared ...bool) error {
-	b, err := newBinding(name, factory, shared...)
+	var opts []FactoryOption
+	if len(shared) > 0 && shared[0] {
+		opts = append(opts, Shared())
+	}
+	return c.NamedFactoryWith(name, factory, opts...)
+}
+
+// FactoryWith 使用配置项绑定一个工厂函数，如 Shared、WithTTL 等。
+func (c *Container) FactoryWith(factory any, opts ...FactoryOption) error {
+	return c.NamedFactoryWith("", factory, opts...)
+}
+
+// NamedFactoryWith 使用配置项具名绑定一个工厂函数。
+func (c *Container) NamedFactoryWith(name string, factory any, opts ...FactoryOption) error {
+	name = c.canonicalName(name)
+	b, err := newBinding(name, factory, opts...)
 	if err != nil {
 		return err
 	}
+	if err = c.checkCycle(b); err != nil {
+		return err
+	}
 	if c.factories == nil {
 		c.factories = make(map[reflect.Type]map[string]*binding)
 	}
@@ -81,111 +387,555 @@ func (c *Container) NamedFactory(name string, factory any, shared ...bool) error
 		c.factories[b.typ] = make(map[string]*binding)
 	}
 	c.factories[b.typ][name] = b
+	c.touch()
 	return nil
 }
 
+// BindSingleton 绑定一个共享的匿名工厂函数，工厂函数最多只会执行一次，
+// 等同于 FactoryWith(factory, Shared())。
+func (c *Container) BindSingleton(factory any) error {
+	return c.FactoryWith(factory, Shared())
+}
+
+// BindTransient 绑定一个非共享的匿名工厂函数，每次获取或注入时都会执行
+// 工厂函数构建新的实例，等同于 FactoryWith(factory)。
+func (c *Container) BindTransient(factory any) error {
+	return c.FactoryWith(factory)
+}
+
+// Provide 批量绑定多个匿名工厂函数，返回所有绑定失败的错误。
+func (c *Container) Provide(factories ...any) error {
+	var errs []error
+	for i, factory := range factories {
+		if err := c.Factory(factory); err != nil {
+			errs = append(errs, fmt.Errorf("ioc: cannot provide factory %d (%T): %w", i, factory, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ProvideNamed 批量绑定多个具名工厂函数，映射的键为绑定的名称，
+// 按照名称顺序依次绑定，返回所有绑定失败的错误。
+func (c *Container) ProvideNamed(entries map[string]any) error {
+	var errs []error
+	for _, name := range sortedKeys(entries) {
+		if err := c.NamedFactory(name, entries[name]); err != nil {
+			errs = append(errs, fmt.Errorf("ioc: cannot provide factory %q: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// BindMany 批量绑定多个具名的“具体实现”，映射的键为绑定的名称，
+// 按照名称顺序依次通过 NamedBind 绑定。
+func (c *Container) BindMany(values map[string]any) {
+	for _, name := range sortedKeys(values) {
+		c.NamedBind(name, values[name])
+	}
+}
+
+// Unbind 从本容器中移除指定类型与名称的“具体实现”与工厂函数，
+// 返回是否有绑定被移除，父容器中的绑定不受影响。
+func (c *Container) Unbind(name string, t reflect.Type) bool {
+	name = c.canonicalName(name)
+	_, instanced := c.instances[t][name]
+	_, bound := c.factories[t][name]
+	delete(c.instances[t], name)
+	delete(c.factories[t], name)
+	c.touch()
+	return instanced || bound
+}
+
+// UnbindAll 从本容器中移除指定类型在所有名称下的“具体实现”与工厂函数，
+// 返回被移除的绑定数量，父容器中的绑定不受影响。
+func (c *Container) UnbindAll(t reflect.Type) int {
+	n := len(c.instances[t]) + len(c.factories[t])
+	delete(c.instances, t)
+	delete(c.factories, t)
+	c.touch()
+	return n
+}
+
+// ResetType 清除指定类型在本容器中所有名称下缓存的共享实例，下次获取时
+// 将重新执行工厂函数构建，工厂函数的注册以及通过 Bind 绑定的值不受影响。
+func (c *Container) ResetType(t reflect.Type) {
+	for _, b := range c.factories[t] {
+		b.instance = reflect.Value{}
+	}
+	c.touch()
+}
+
+// CacheStats 返回共享实例缓存的命中次数与未命中（执行工厂函数）的次数。
+func (c *Container) CacheStats() (hits, misses int64) {
+	return c.hits.Load(), c.misses.Load()
+}
+
 // Get 获取指定类型的“具体实现”值，获取步骤如下：
 // * 1、使用事先通过 Bind 方法绑定了值；
 // * 2、执行 Factory 方法绑定的工厂函数；
 // * 3、若无法通过上述途径获取，且类型是结构体或结构体指针时，尝试构建一个实例。
+//
+// 同一类型与名称同时存在绑定的值与工厂函数时，默认使用绑定的值，
+// 使用 WithFactoryPrecedence 配置项可以让工厂函数优先。
 func (c *Container) Get(t reflect.Type) (reflect.Value, error) {
-	return c.get("", t)
+	return c.get(context.Background(), "", t)
 }
 
 // NamedGet 具名方式获取指定类型的“具体实现”值，该方法与 Get 类似。
+//
+// 名称可以是运行时计算得到的任意字符串（包括 Unicode 以及空白字符等），
+// 除了经过 WithNameResolver 规范化之外不做任何处理，空字符串表示匿名绑定，
+// 因此可以用于插件式的分发：
+//
+//	c.NamedGet(plugin.Name(), handlerType)
 func (c *Container) NamedGet(name string, t reflect.Type) (reflect.Value, error) {
-	return c.get(name, t)
+	return c.get(context.Background(), name, t)
+}
+
+// GetContext 获取指定类型的“具体实现”值，上下文 ctx 会在整个解析过程中传递，
+// 用于选择 BindForContext 绑定的值等场景。
+func (c *Container) GetContext(ctx context.Context, t reflect.Type) (reflect.Value, error) {
+	return c.NamedGetContext(ctx, "", t)
+}
+
+// NamedGetContext 具名方式获取指定类型的“具体实现”值，该方法与 GetContext 类似。
+func (c *Container) NamedGetContext(ctx context.Context, name string, t reflect.Type) (reflect.Value, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return c.get(ctx, name, t)
+}
+
+// GetWithTimeout 在限定的时间 d 内获取指定类型与名称的“具体实现”值，超时后
+// 返回包装了 context.DeadlineExceeded 的错误。需要注意的是，解析是在另一个
+// goroutine 中进行的，超时之后正在执行的工厂函数并不会被中断，而是会在后台
+// 继续执行直到完成，其构建的共享实例依然会被缓存。
+//
+// 由于容器不是并发安全的，后台的解析会继续修改容器（以及父容器）的状态，
+// 如缓存共享实例、记录需要关闭的实例等，因此超时之后，在后台的解析完成之前
+// 不能再使用该容器及其父子容器，否则会产生数据竞争；通常只应在超时即意味着
+// 放弃整个容器（如启动失败后退出）的场景中使用。
+func (c *Container) GetWithTimeout(ctx context.Context, name string, t reflect.Type, d time.Duration) (reflect.Value, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	type result struct {
+		val reflect.Value
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		val, err := c.get(ctx, name, t)
+		done <- result{val, err}
+	}()
+	select {
+	case r := <-done:
+		return r.val, r.err
+	case <-ctx.Done():
+		return reflect.Value{}, fmt.Errorf("ioc: resolving %v timed out after %v: %w", t, d, ctx.Err())
+	}
+}
+
+func (c *Container) get(ctx context.Context, name string, t reflect.Type) (reflect.Value, error) {
+	return c.find(ctx, name, t, true)
+}
+
+// find 获取指定类型与名称的值，参数 construct 为 false 时不会自动构建
+// 未绑定的结构体（或结构体指针）。设置了 WithSlogger 时记录每次解析的日志。
+func (c *Container) find(ctx context.Context, name string, t reflect.Type, construct bool) (reflect.Value, error) {
+	logger := c.opts.slogger
+	if logger == nil {
+		return c.findValue(ctx, name, t, construct)
+	}
+	var source string
+	start := time.Now()
+	val, err := c.findValue(context.WithValue(ctx, sourceKey, &source), name, t, construct)
+	attrs := []slog.Attr{
+		slog.String("type", fmt.Sprint(t)),
+		slog.String("name", name),
+		slog.String("source", source),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	logger.LogAttrs(ctx, slog.LevelDebug, "ioc: resolve", attrs...)
+	return val, err
+}
+
+// setSource 记录解析到的值的来源，仅在设置了 WithSlogger 时生效
+func setSource(ctx context.Context, source string) {
+	if p, ok := ctx.Value(sourceKey).(*string); ok {
+		*p = source
+	}
 }
 
-func (c *Container) get(name string, t reflect.Type) (reflect.Value, error) {
+func (c *Container) findValue(ctx context.Context, name string, t reflect.Type, construct bool) (reflect.Value, error) {
 	if t == nil {
-		return reflect.Value{}, ErrValueNotFound
+		return reflect.Value{}, &NotFoundError{Name: name}
 	}
-	// 获取通过 Bind 或 NamedBind 绑定的值
-	values, instanced := c.instances[t]
-	if instanced {
-		value, exists := values[name]
-		if exists && value.IsValid() {
-			return value, nil
+	// 通过上下文记录解析的嵌套深度，防止递归的依赖图耗尽调用栈
+	depth, _ := ctx.Value(depthKey).(int)
+	if max := c.opts.depthLimit(); depth >= max {
+		return reflect.Value{}, fmt.Errorf("%w (%d) while resolving %v", ErrMaxDepthExceeded, max, t)
+	}
+	ctx = context.WithValue(ctx, depthKey, depth+1)
+	name = c.canonicalName(name)
+	if val, ok := c.lookupContext(ctx, name, t); ok {
+		setSource(ctx, "context")
+		return val, nil
+	}
+	if name == "" && c.opts.defaultName != "" {
+		// 未指定名称时优先使用以默认名称绑定的值，没有时再使用匿名绑定
+		val, _, err := c.lookup(ctx, c.canonicalName(c.opts.defaultName), t)
+		if !notFound(err) {
+			return val, err
 		}
 	}
-	// 通过执行 Factory 或 NamedFactory 绑定的工厂函数获取值
-	bindings, bound := c.factories[t]
-	if bound {
-		bind, exists := bindings[name]
-		if exists {
-			val, err := bind.make(c)
-			if err != nil {
-				// TODO(hupeh): 更加友好的错误信息
-				return reflect.Value{}, err
-			}
-			if val.IsValid() {
-				return val, nil
-			}
+	val, _, err := c.lookup(ctx, name, t)
+	if !notFound(err) {
+		return val, err
+	}
+	if name != "" && c.opts.nameFallback {
+		// 找不到具名的绑定时使用匿名的绑定
+		val, _, err := c.lookup(ctx, "", t)
+		if !notFound(err) {
+			return val, err
 		}
 	}
 
-	// 使用同名但不同类型里面可以被转换或被实现的
-	for rt, values := range c.instances {
-		if rt != t && (t.Kind() == reflect.Interface && rt.Implements(t) || rt.AssignableTo(t)) {
-			val, ok := values[name]
-			if ok {
-				return val, nil
-			}
+	// 别名可以定义在父容器中，但目标类型总是从当前容器开始查找
+	if target := c.aliasOf(t); target != nil {
+		return c.find(ctx, name, target, construct)
+	}
+
+	if val, ok := c.lookupDefault(name, t); ok {
+		setSource(ctx, "default")
+		return val, nil
+	}
+
+	if c.opts.funcProviders && c.hasProvider(name, t) {
+		setSource(ctx, "provider")
+		return c.makeProvider(ctx, name, t), nil
+	}
+
+	// 多级指针（如 **Service），获取其元素类型的值，然后分配指针链
+	if t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Pointer {
+		val, err := c.find(ctx, name, t.Elem(), construct)
+		if err != nil {
+			return reflect.Value{}, err
 		}
+		ptr := reflect.New(t.Elem())
+		ptr.Elem().Set(val)
+		return ptr, nil
 	}
-	// 查看注的册工厂函数，看它们的具体实现是否可以被转换或被实现的
-	for rt, bindings := range c.factories {
-		if t != rt {
-			switch {
-			case t.Kind() == reflect.Interface && rt.Implements(t):
-			case rt.AssignableTo(t):
-				bind, ok := bindings[""]
-				if !ok {
-					continue
-				}
-				val, err := bind.make(c)
-				if err != nil {
-					continue
-				}
-				if val.IsValid() {
-					return val, nil
-				}
-			}
+
+	// 请求的是指针，但是绑定的是其元素类型的值（如绑定了 Config 而请求 *Config），
+	// 则复制一份可寻址的副本并返回其指针，通过指针所做的修改不会影响绑定的值
+	if t.Kind() == reflect.Pointer {
+		val, _, err := c.lookup(ctx, name, t.Elem())
+		if err == nil && val.IsValid() {
+			ptr := reflect.New(t.Elem())
+			ptr.Elem().Set(val)
+			return ptr, nil
+		}
+		if err != nil && !notFound(err) {
+			return reflect.Value{}, err
+		}
+	}
+
+	// 请求的是值，但是绑定的是其指针（如绑定了 *Config 而请求 Config），
+	// 启用 WithPointerAdaptation 时返回指针指向的值的副本
+	if c.opts.pointerAdaptation && t.Kind() != reflect.Pointer {
+		val, _, err := c.lookup(ctx, name, reflect.PointerTo(t))
+		if err == nil && val.IsValid() && !val.IsNil() {
+			return val.Elem(), nil
+		}
+		if err != nil && !notFound(err) {
+			return reflect.Value{}, err
 		}
 	}
 
-	if c.parent != nil {
-		return c.NamedGet(name, t)
+	if !construct || c.hidden(name, t) {
+		return reflect.Value{}, &NotFoundError{Type: t, Name: name}
 	}
 
-	rt := t
-	if rt.Kind() == reflect.Pointer {
-		rt = rt.Elem()
+	// 自动构建的结构体中使用 `ioc:",name"` 标签的字段将被注入该名称
+	ctx = context.WithValue(ctx, nameKey, name)
+
+	// 如果给的是结构体指针，则构建结构体并返回其指针
+	if t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct {
+		rv := reflect.New(t.Elem())
+		err := c.resolve(ctx, &rv, resolveOptions{})
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		setSource(ctx, "construct")
+		return rv, nil
 	}
 
-	// 如果给的是结构体，则直接构建
+	// 如果给的是结构体，则直接构建，返回的是结构体的值而不是指针，
+	// 因此工厂函数可以使用带有 ioc 标签的配置结构体作为参数
 	if t.Kind() == reflect.Struct {
 		rv := reflect.New(t)
-		err := c.resolve(&rv)
+		err := c.resolve(ctx, &rv, resolveOptions{})
 		if err != nil {
 			return reflect.Value{}, err
 		}
-		// TODO(hupeh): 对于结构体指针怎么处理？
-		return rv, nil
+		setSource(ctx, "construct")
+		return rv.Elem(), nil
 	}
 
-	return reflect.Value{}, ErrValueNotFound
+	return reflect.Value{}, &NotFoundError{Type: t, Name: name}
+}
+
+// lookup 在本容器中查找已注册的“具体实现”，找不到时沿着父容器链继续查找，
+// 但不会自动构建结构体，自动构建始终在发起查找的容器中进行。
+func (c *Container) lookup(ctx context.Context, name string, t reflect.Type) (val reflect.Value, stable bool, err error) {
+	if c.opts.factoryPrecedence {
+		if val, stable, ok, err := c.lookupFactory(ctx, name, t); ok || err != nil {
+			setSource(ctx, "factory")
+			return val, stable, err
+		}
+		if val, ok := c.lookupInstance(name, t); ok {
+			setSource(ctx, "instance")
+			return val, true, nil
+		}
+	} else {
+		if val, ok := c.lookupInstance(name, t); ok {
+			setSource(ctx, "instance")
+			return val, true, nil
+		}
+		if val, stable, ok, err := c.lookupFactory(ctx, name, t); ok || err != nil {
+			setSource(ctx, "factory")
+			return val, stable, err
+		}
+	}
+
+	// 使用同名但不同类型里面可以被转换或被实现的，建立了索引的接口直接使用索引，
+	// 有多个这样的类型时无法确定使用哪一个，返回 ErrAmbiguousBinding
+	candidates, factoryCandidates, indexed := c.indexedTypes(name, t)
+	if !indexed {
+		for rt, values := range c.instances {
+			if _, ok := values[name]; ok && rt != t && rt.AssignableTo(t) {
+				candidates = append(candidates, rt)
+			}
+		}
+		sortTypes(candidates)
+	}
+	switch distinct := c.distinctInstances(name, candidates); len(distinct) {
+	case 0:
+	case 1:
+		for _, rt := range candidates {
+			c.markUsed(rt, name)
+		}
+		setSource(ctx, "scan")
+		return c.instances[distinct[0]][name], true, nil
+	default:
+		return reflect.Value{}, false, ambiguousError(name, t, distinct)
+	}
+
+	// 查看注的册工厂函数，看它们的具体实现是否可以被转换或被实现的
+	candidates = factoryCandidates
+	if !indexed {
+		for rt, bindings := range c.factories {
+			if b, ok := bindings[name]; ok && rt != t && rt.AssignableTo(t) && b.reachableAs(t) {
+				candidates = append(candidates, rt)
+			}
+		}
+		sortTypes(candidates)
+	}
+	switch len(candidates) {
+	case 0:
+	case 1:
+		bind := c.factories[candidates[0]][name]
+		c.markUsed(candidates[0], name)
+		val, err := bind.make(ctx, c)
+		if err != nil {
+			return reflect.Value{}, false, err
+		}
+		if val.IsValid() {
+			setSource(ctx, "scan")
+			return val, bind.stable(), nil
+		}
+	default:
+		return reflect.Value{}, false, ambiguousError(name, t, candidates)
+	}
+
+	if val, ok := c.cachedValue(name, t); ok {
+		setSource(ctx, "parent")
+		return val, true, nil
+	}
+	for _, p := range c.parents {
+		val, stable, err := p.lookup(ctx, name, t)
+		if !notFound(err) {
+			setSource(ctx, "parent")
+			if err == nil && stable {
+				c.cacheValue(name, t, val)
+			}
+			return val, stable, err
+		}
+	}
+
+	return reflect.Value{}, false, ErrValueNotFound
+}
+
+// hasProvider 判断类型 t 是否为形如 func() T 或 func() (T, error) 的函数，
+// 并且 T 注册了对应名称的工厂函数。
+func (c *Container) hasProvider(name string, t reflect.Type) bool {
+	if t.Kind() != reflect.Func || t.NumIn() != 0 {
+		return false
+	}
+	switch t.NumOut() {
+	case 1:
+	case 2:
+		if t.Out(1) != errorType {
+			return false
+		}
+	default:
+		return false
+	}
+	for _, ci := range c.lineage() {
+		if _, ok := ci.factories[t.Out(0)][name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// makeProvider 合成一个类型为 t 的函数，每次调用时都从容器中获取值，
+// 对于没有返回错误的函数，获取失败时将触发 panic。
+func (c *Container) makeProvider(ctx context.Context, name string, t reflect.Type) reflect.Value {
+	out := t.Out(0)
+	return reflect.MakeFunc(t, func([]reflect.Value) []reflect.Value {
+		val, err := c.get(ctx, name, out)
+		if err != nil {
+			if t.NumOut() == 1 {
+				panic(err)
+			}
+			return []reflect.Value{reflect.Zero(out), reflect.ValueOf(&err).Elem()}
+		}
+		if t.NumOut() == 1 {
+			return []reflect.Value{val}
+		}
+		return []reflect.Value{val, reflect.Zero(errorType)}
+	})
+}
+
+// lookupInstance 获取本容器中通过 Bind 或 NamedBind 绑定的值
+func (c *Container) lookupInstance(name string, t reflect.Type) (reflect.Value, bool) {
+	values, instanced := c.instances[t]
+	if instanced {
+		value, exists := values[name]
+		if exists && value.IsValid() {
+			c.markUsed(t, name)
+			return value, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// lookupFactory 通过执行本容器中 Factory 或 NamedFactory 绑定的工厂函数获取值，
+// 返回值 stable 表示该值是否为可以重复使用的共享实例。
+func (c *Container) lookupFactory(ctx context.Context, name string, t reflect.Type) (val reflect.Value, stable, ok bool, err error) {
+	bindings, bound := c.factories[t]
+	if bound {
+		bind, exists := bindings[name]
+		if exists && bind.reachableAs(t) {
+			c.markUsed(t, name)
+			val, err := bind.make(ctx, c)
+			if err != nil {
+				return reflect.Value{}, false, false, err
+			}
+			if val.IsValid() {
+				return val, bind.stable(), true, nil
+			}
+		}
+	}
+	return reflect.Value{}, false, false, nil
 }
 
 // Resolve 依赖注入，在结构体中，可以通过指定一个名为 ioc 的 tag 表明
-// 使用的指定的名称的“具体实现”来完成注入。
+// 使用的指定的名称的“具体实现”来完成注入，tag 的格式为 `ioc:"name,option..."`，
+// 支持的选项如下：
+//
+//   - omitempty：找不到“具体实现”时忽略该字段；
+//   - fresh：即使工厂函数是共享的，也总是为该字段构建新的实例，该实例
+//     不会被缓存，容器关闭时也不会释放它，需要由使用者自行管理其生命周期。
+//   - lazy：字段的类型必须是 Lazy[T] 或 *Lazy[T]，其值在首次调用 Get 时才获取。
+//   - required：即使启用了 WithSoftResolve，找不到“具体实现”时依然返回错误；
+//   - name：字段的类型必须是字符串，注入的是结构体被获取时所使用的名称（如通过
+//     NamedGet("foo", ...) 自动构建时为 "foo"，作为工厂函数的参数对象时为工厂函数
+//     绑定的名称），适用于需要知道自身名称的插件，如 `ioc:",name"`。
 func (c *Container) Resolve(i any) error {
 	v := reflect.ValueOf(i)
-	return c.resolve(&v)
+	return c.resolve(context.Background(), &v, resolveOptions{})
+}
+
+// ResolveContext 与 Resolve 类似，但上下文 ctx 会在整个解析过程中传递，
+// 类型为 context.Context 的字段将被注入该上下文。
+func (c *Container) ResolveContext(ctx context.Context, i any) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	v := reflect.ValueOf(i)
+	return c.resolve(ctx, &v, resolveOptions{})
+}
+
+// ResolveInto 与 Resolve 类似，但只注入值为零值的字段，已经设置了值的字段
+// 保持不变，用于将容器中的值合并到已经部分初始化的结构体中。对于零值字段，
+// omitempty 的含义不变，即找不到“具体实现”时保持零值。
+func (c *Container) ResolveInto(i any) error {
+	v := reflect.ValueOf(i)
+	return c.resolve(context.Background(), &v, resolveOptions{merge: true})
+}
+
+// ResolveWithFallback 与 Resolve 类似，但每个字段先从本容器（及其父容器）中查找，
+// 找不到时再从容器 fallback 中查找，适用于请求级别的容器提供覆盖值而根容器提供
+// 默认值的场景。结构体的自动构建只在两者都找不到时才进行，并使用本容器的配置项。
+func (c *Container) ResolveWithFallback(i any, fallback *Container) error {
+	if fallback == nil {
+		return c.Resolve(i)
+	}
+	composed := &Container{opts: c.opts, parents: []*Container{c, fallback}}
+	return composed.Resolve(i)
 }
 
-func (c *Container) resolve(rv *reflect.Value) error {
+// ResolveValue 与 Resolve 类似，但接受按值传递的结构体，返回完成注入的副本，
+// 参数 v 本身不会被修改。
+func (c *Container) ResolveValue(v any) (any, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("ioc: must given a struct value, got %T", v)
+	}
+	pv := reflect.New(rv.Type())
+	pv.Elem().Set(rv)
+	if err := c.resolve(context.Background(), &pv, resolveOptions{}); err != nil {
+		return nil, err
+	}
+	return pv.Elem().Interface(), nil
+}
+
+// ResolveAll 依次对每个目标执行 Resolve，并合并所有的错误，
+// 错误信息中包含失败目标的序号与类型。
+func (c *Container) ResolveAll(targets ...any) error {
+	var errs []error
+	for i, target := range targets {
+		if err := c.Resolve(target); err != nil {
+			errs = append(errs, fmt.Errorf("ioc: cannot resolve target %d (%T): %w", i, target, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resolveOptions 注入结构体时的选项
+type resolveOptions struct {
+	merge bool // 跳过已经设置了值（非零值）的字段
+}
+
+func (c *Container) resolve(ctx context.Context, rv *reflect.Value, ro resolveOptions) error {
 	v := *rv
 	for v.Kind() == reflect.Ptr {
 		v = v.Elem()
@@ -193,59 +943,594 @@ func (c *Container) resolve(rv *reflect.Value) error {
 	if v.Kind() != reflect.Struct {
 		return errors.New("ioc: must given a struct")
 	}
+	if !v.CanAddr() {
+		// 按值传递的结构体是一个副本，注入的结果对调用方不可见
+		return fmt.Errorf("ioc: must given a pointer to struct, got %v", v.Type())
+	}
 	t := v.Type()
 	for i := 0; i < v.NumField(); i++ {
 		f := v.Field(i)
-		tag, omitempty, inject := parseTag(t.Field(i))
+		field := t.Field(i)
+		tag := parseTag(field)
 		if !f.CanSet() {
-			if inject && !omitempty {
-				return fmt.Errorf("ioc: cannot make %v field", t.Field(i).Name)
+			if tag.inject && !tag.omitempty {
+				return fmt.Errorf("ioc: cannot make %v field", field.Name)
 			}
 			continue
 		}
+		if field.Anonymous && field.Type == inType {
+			continue
+		}
+		if field.Anonymous && isInStruct(field.Type) {
+			// 展开嵌入的参数对象，其字段与当前结构体的字段一样被注入
+			fv := f.Addr()
+			if err := c.resolve(ctx, &fv, ro); err != nil {
+				return err
+			}
+			continue
+		}
+		if ro.merge && !f.IsZero() {
+			continue
+		}
+		if tag.resolvedName {
+			if f.Kind() != reflect.String {
+				return fmt.Errorf("ioc: field %s with the name option must be a string", field.Name)
+			}
+			name, _ := ctx.Value(nameKey).(string)
+			f.SetString(name)
+			continue
+		}
 		ft := f.Type()
-		fv, err := c.NamedGet(tag, ft)
+		fv, err := c.resolveField(ctx, field, tag)
 		if err != nil {
-			if omitempty {
+			if c.optional(tag) {
 				continue
 			}
-			// TODO(hupeh): 更加友好的错误提示
-			return err
+			if c.opts.zeroMissingPrimitives && notFound(err) && isPrimitive(ft) {
+				f.Set(reflect.Zero(ft))
+				continue
+			}
+			return fieldError(t, field, err)
 		}
 		if !fv.IsValid() {
 			return fmt.Errorf("ioc: value not found for type %v", ft)
 		}
 		f.Set(fv)
 	}
+	if c.opts.setterInjection {
+		if err := c.injectSetters(ctx, v.Addr()); err != nil {
+			return err
+		}
+	}
 	rv = &v
 	return nil
 }
 
+// injectSetters 调用结构体指针 pv 上所有的 setter 方法，即名称为 Set 后跟大写
+// 字母（如 SetLogger，而不是 Setup、Settle）、只有一个参数、没有返回值或者只
+// 返回一个错误的导出方法，如：
+//
+//	func (s *Service) SetLogger(logger *Logger)
+//	func (s *Service) SetCache(cache Cache) error
+//
+// 从嵌入字段提升的方法（如嵌入 *log.Logger 得到的 SetPrefix、SetOutput）不是
+// 结构体自身的 setter，会被跳过。参数从容器中匿名获取，但不会自动构建未绑定的
+// 结构体（如 SetDeadline(time.Time) 不会被传入零值），找不到时跳过该方法，
+// setter 返回的错误会被返回。
+func (c *Container) injectSetters(ctx context.Context, pv reflect.Value) error {
+	pt := pv.Type()
+	for i := 0; i < pt.NumMethod(); i++ {
+		m := pt.Method(i)
+		mt := m.Type // 第一个参数是接收者
+		if !isSetterName(m.Name) || mt.NumIn() != 2 ||
+			mt.NumOut() > 1 || mt.NumOut() == 1 && mt.Out(0) != errorType ||
+			isPromoted(pt.Elem(), m.Name) {
+			continue
+		}
+		arg, err := c.find(ctx, "", mt.In(1), false)
+		if notFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("ioc: cannot resolve argument of %v.%s: %w", pt, m.Name, err)
+		}
+		out := pv.Method(i).Call([]reflect.Value{arg})
+		if len(out) == 1 && !out[0].IsNil() {
+			return fmt.Errorf("ioc: %v.%s: %w", pt, m.Name, out[0].Interface().(error))
+		}
+	}
+	return nil
+}
+
+// isSetterName 判断方法名称是否为 Set 后跟一个大写字母
+func isSetterName(name string) bool {
+	rest, ok := strings.CutPrefix(name, "Set")
+	if !ok {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	return unicode.IsUpper(r)
+}
+
+// isPromoted 判断结构体 st 的方法 name 是否是从嵌入字段提升而来的，结构体自身
+// 声明的同名方法会遮蔽嵌入字段的方法，但通过反射无法区分这两种情况，因此与
+// 嵌入字段的方法同名的方法都被视为提升的方法。
+func isPromoted(st reflect.Type, name string) bool {
+	if st.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < st.NumField(); i++ {
+		f := st.Field(i)
+		if !f.Anonymous {
+			continue
+		}
+		ft := f.Type
+		if ft.Kind() != reflect.Pointer && ft.Kind() != reflect.Interface {
+			// 通过结构体指针可以调用嵌入值的指针接收者方法
+			ft = reflect.PointerTo(ft)
+		}
+		if _, ok := ft.MethodByName(name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// optional 判断字段找不到“具体实现”时是否可以忽略
+func (c *Container) optional(tag fieldTag) bool {
+	return tag.omitempty || c.opts.softResolve && !tag.required
+}
+
+// resolveField 获取需要注入到字段中的值
+func (c *Container) resolveField(ctx context.Context, field reflect.StructField, tag fieldTag) (reflect.Value, error) {
+	ft := field.Type
+	if fn, ok := c.opts.fieldResolvers[ft]; ok {
+		return fn(c, field)
+	}
+	if ft == contextType {
+		// context.Context 类型的字段注入解析过程所使用的上下文
+		return reflect.ValueOf(&ctx).Elem(), nil
+	}
+	if tag.fresh {
+		return c.getFresh(ctx, tag.name, ft)
+	}
+	if tag.lazy || isLazy(ft) {
+		return c.makeLazy(ctx, tag.name, ft)
+	}
+	var fv reflect.Value
+	var err error
+	if !tag.inject && c.opts.fieldNameAsName {
+		// 未指定 tag 时优先使用字段名称作为绑定名称，但不自动构建结构体，
+		// 否则匿名绑定的值永远不会被使用
+		fv, err = c.find(ctx, field.Name, ft, false)
+	}
+	if tag.inject || !c.opts.fieldNameAsName || notFound(err) {
+		fv, err = c.get(ctx, tag.name, ft)
+	}
+	if notFound(err) && tag.name == "" && isCollection(ft) {
+		// 未绑定切片或映射本身时，收集所有实现了元素接口（或者类型与元素相同）的
+		// “具体实现”，什么也没有收集到时与其它缺失的值一样，可以使用 omitempty 忽略
+		fv, err = c.collectInto(ctx, ft)
+	}
+	return fv, err
+}
+
+// getFresh 获取指定类型的值，对于共享的工厂函数，忽略已缓存的实例而重新构建，
+// 新构建的实例不会被缓存，也不会在容器关闭时被释放；工厂函数的查找与 lookup
+// 一致（包括类型扫描），由绑定的值满足时与 NamedGet 相同。
+func (c *Container) getFresh(ctx context.Context, name string, t reflect.Type) (reflect.Value, error) {
+	if b, owner := c.findBinding(c.canonicalName(name), t); b != nil {
+		owner.markUsed(b.typ, b.name)
+		return b.build(ctx, owner)
+	}
+	return c.get(ctx, name, t)
+}
+
+// findBinding 沿着父容器链查找获取指定类型与名称时将被执行的工厂函数，同时返回
+// 其所属的容器，查找顺序与 lookup 一致，由绑定的值满足或者类型扫描的结果不唯一时
+// 返回 nil。
+func (c *Container) findBinding(name string, t reflect.Type) (*binding, *Container) {
+	for _, ci := range c.lineage() {
+		_, instanced := ci.instances[t][name]
+		b, ok := ci.factories[t][name]
+		if ok && b.reachableAs(t) && (ci.opts.factoryPrecedence || !instanced) {
+			return b, ci
+		}
+		if instanced {
+			return nil, nil
+		}
+		for rt, values := range ci.instances {
+			if _, ok := values[name]; ok && rt != t && rt.AssignableTo(t) {
+				return nil, nil
+			}
+		}
+		var candidates []*binding
+		for rt, bindings := range ci.factories {
+			if b, ok := bindings[name]; ok && rt != t && rt.AssignableTo(t) && b.reachableAs(t) {
+				candidates = append(candidates, b)
+			}
+		}
+		switch len(candidates) {
+		case 0:
+		case 1:
+			return candidates[0], ci
+		default:
+			return nil, nil
+		}
+	}
+	return nil, nil
+}
+
+// distinctInstances 去掉 types 中与之前的类型以名称 name 绑定了同一个值的类型，
+// 如 BindWithInterfaces 同时以实际类型与接口绑定的值只作为一个候选。
+func (c *Container) distinctInstances(name string, types []reflect.Type) []reflect.Type {
+	if len(types) < 2 {
+		return types
+	}
+	var distinct []reflect.Type
+	for _, rt := range types {
+		val := c.instances[rt][name]
+		if !slices.ContainsFunc(distinct, func(seen reflect.Type) bool {
+			return sameValue(c.instances[seen][name], val)
+		}) {
+			distinct = append(distinct, rt)
+		}
+	}
+	return distinct
+}
+
+// ambiguousError 返回列出了所有候选类型的 ErrAmbiguousBinding 错误
+func ambiguousError(name string, t reflect.Type, candidates []reflect.Type) error {
+	names := make([]string, len(candidates))
+	for i, rt := range candidates {
+		names[i] = rt.String()
+	}
+	return fmt.Errorf("%w: %v named %q is satisfied by %s", ErrAmbiguousBinding, t, name, strings.Join(names, ", "))
+}
+
+// notFound 判断错误是否表示要获取的值本身不存在，构建过程中由于依赖缺失
+// 而产生的错误虽然也包装了 ErrValueNotFound，但不属于这种情况。
+func notFound(err error) bool {
+	if err == ErrValueNotFound {
+		return true
+	}
+	_, ok := err.(*NotFoundError)
+	return ok
+}
+
+// fieldError 为字段注入失败的错误附加结构体与字段信息，嵌套结构体
+// 构建失败时，错误信息将包含完整的解析路径，如：
+//
+//	ioc: cannot resolve Outer.Inner (*pkg.Inner): ioc: cannot resolve Inner.bar (pkg.Bar): ioc: value not found for type pkg.Bar
+//
+// 原始的 *NotFoundError 会被保留，可以通过 errors.As 获取缺失的类型与名称。
+func fieldError(t reflect.Type, field reflect.StructField, err error) error {
+	name := t.Name()
+	if name == "" {
+		name = t.String()
+	}
+	if err == ErrValueNotFound {
+		err = &NotFoundError{Type: field.Type}
+	}
+	return fmt.Errorf("ioc: cannot resolve %s.%s (%v): %w", name, field.Name, field.Type, err)
+}
+
+// GetAll 获取所有实现了接口 t 的“具体实现”，包括本容器与父容器中绑定的值，
+// 以及返回值实现了接口 t 的工厂函数所构建的实例，顺序是确定的。
+//
+// 需要注意的是，这会执行所有匹配的工厂函数，代价可能较高，使用配置项
+// WithCollectFactories(false) 可以只收集已经构建并缓存的共享实例。
+func (c *Container) GetAll(t reflect.Type) ([]reflect.Value, error) {
+	entries, err := c.collect(context.Background(), t, !c.opts.skipCollectFactories)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]reflect.Value, len(entries))
+	for i, e := range entries {
+		values[i] = e.value
+	}
+	return values, nil
+}
+
+// collectInto 构建一个 []Interface 或 map[string]Interface 类型的值，其元素为
+// 容器（及其父容器）中所有实现了该接口的“具体实现”：切片包含所有的“具体实现”，
+// 映射则以绑定的名称为键，名称相同时以先收集到的为准。元素为结构体（或其指针）
+// 时，收集的是以不同名称绑定的该类型的值，如 []Service 与 map[string]*Service。
+func (c *Container) collectInto(ctx context.Context, t reflect.Type) (reflect.Value, error) {
+	entries, err := c.collect(ctx, t.Elem(), !c.opts.skipCollectFactories)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if len(entries) == 0 {
+		return reflect.Value{}, ErrValueNotFound
+	}
+	if t.Kind() == reflect.Map {
+		mv := reflect.MakeMapWithSize(t, len(entries))
+		for _, e := range entries {
+			key := reflect.ValueOf(e.name).Convert(t.Key())
+			if !mv.MapIndex(key).IsValid() {
+				mv.SetMapIndex(key, e.value)
+			}
+		}
+		return mv, nil
+	}
+	sv := reflect.MakeSlice(t, 0, len(entries))
+	for _, e := range entries {
+		sv = reflect.Append(sv, e.value)
+	}
+	return sv, nil
+}
+
+// collected 收集到的“具体实现”及其绑定的名称
+type collected struct {
+	name  string
+	value reflect.Value
+}
+
+// collect 收集所有实现了接口 t（或能够赋值给类型 t）的“具体实现”，先本容器后父容器，
+// 同一容器内按照类型与名称排序，保证结果的顺序是确定的。
+//
+// 参数 build 为 true 时，会执行返回值实现了接口 t 的工厂函数（共享的实例
+// 会被缓存），否则只收集已经构建并缓存的共享实例。
+func (c *Container) collect(ctx context.Context, t reflect.Type, build bool) ([]collected, error) {
+	var result []collected
+	for _, ci := range c.lineage() {
+		types := make([]reflect.Type, 0, len(ci.instances))
+		for rt := range ci.instances {
+			if rt != nil && rt.AssignableTo(t) {
+				types = append(types, rt)
+			}
+		}
+		sortTypes(types)
+		start := len(result)
+		for _, rt := range types {
+			values := ci.instances[rt]
+			for _, name := range sortedKeys(values) {
+				val := values[name]
+				if !val.IsValid() {
+					continue
+				}
+				ci.markUsed(rt, name)
+				// 同一个值以多个类型绑定时（如 BindWithInterfaces）只收集一次
+				if !slices.ContainsFunc(result[start:], func(r collected) bool {
+					return r.name == name && sameValue(r.value, val)
+				}) {
+					result = append(result, collected{name, val})
+				}
+			}
+		}
+		// 工厂函数构建的实例
+		types = types[:0]
+		for rt := range ci.factories {
+			if rt.AssignableTo(t) {
+				types = append(types, rt)
+			}
+		}
+		sortTypes(types)
+		for _, rt := range types {
+			bindings := ci.factories[rt]
+			for _, name := range sortedKeys(bindings) {
+				b := bindings[name]
+				if !b.reachableAs(t) {
+					continue
+				}
+				if !build {
+					if b.instance.IsValid() {
+						ci.markUsed(rt, name)
+						result = append(result, collected{name, b.instance})
+					}
+					continue
+				}
+				ci.markUsed(rt, name)
+				val, err := b.make(ctx, ci)
+				if err != nil {
+					return nil, err
+				}
+				if val.IsValid() {
+					result = append(result, collected{name, val})
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
 // Invoke 执行指定的函数，使用服务容器完成参数注入。
 func (c *Container) Invoke(fn any) ([]reflect.Value, error) {
 	rt := reflect.TypeOf(fn)
-	if rt.Kind() != reflect.Func {
-		return nil, errors.New("ioc: Out of non-func type " + rt.String())
+	if err := checkFunc(rt); err != nil {
+		return nil, err
 	}
-	return c.invoke(rt, reflect.ValueOf(fn))
+	return c.invoke(context.Background(), rt, reflect.ValueOf(fn), c.invokeOptions())
 }
 
-func (c *Container) invoke(rt reflect.Type, rv reflect.Value) ([]reflect.Value, error) {
+// InvokeContext 与 Invoke 类似，但上下文 ctx 会在整个解析过程中传递，
+// 函数中类型为 context.Context 的参数直接使用 ctx，而不会从容器中获取。
+func (c *Container) InvokeContext(ctx context.Context, fn any) ([]reflect.Value, error) {
+	rt := reflect.TypeOf(fn)
+	if err := checkFunc(rt); err != nil {
+		return nil, err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return c.invoke(ctx, rt, reflect.ValueOf(fn), c.invokeOptions())
+}
+
+// ResolveMethod 获取类型为 recvType 的接收者，然后执行其名为 methodName 的方法，
+// 方法的参数与 Invoke 一样使用服务容器完成注入，如 (*App).Run：
+//
+//	c.ResolveMethod(reflect.TypeOf((*App)(nil)), "Run")
+func (c *Container) ResolveMethod(recvType reflect.Type, methodName string) ([]reflect.Value, error) {
+	ctx := context.Background()
+	recv, err := c.get(ctx, "", recvType)
+	if err != nil {
+		return nil, fmt.Errorf("ioc: cannot resolve receiver %v: %w", recvType, err)
+	}
+	method := recv.MethodByName(methodName)
+	if !method.IsValid() && recv.CanAddr() {
+		// 值类型的接收者同样可以调用指针接收者的方法
+		method = recv.Addr().MethodByName(methodName)
+	}
+	if !method.IsValid() {
+		return nil, fmt.Errorf("ioc: %v has no method %q", recvType, methodName)
+	}
+	return c.invoke(ctx, method.Type(), method, c.invokeOptions())
+}
+
+// InvokeWith 执行指定的函数，参数 provided 按照类型依次匹配函数中第一个
+// 可以赋值的参数，其余的参数使用服务容器完成注入。provided 中不能有无类型的
+// nil，因为无法据此确定要匹配的参数，需要传入 nil 时应使用有类型的 nil，
+// 如 (*Foo)(nil)。
+func (c *Container) InvokeWith(fn any, provided ...any) ([]reflect.Value, error) {
+	rt := reflect.TypeOf(fn)
+	if err := checkFunc(rt); err != nil {
+		return nil, err
+	}
+	values := make([]reflect.Value, len(provided))
+	for i, p := range provided {
+		if p == nil {
+			return nil, fmt.Errorf("ioc: provided argument %d is an untyped nil", i+1)
+		}
+		values[i] = reflect.ValueOf(p)
+	}
+	return c.invoke(context.Background(), rt, reflect.ValueOf(fn), c.invokeOptions(), values...)
+}
+
+// InvokeCleanup 与 Invoke 相同，同时将函数所有 func() 类型的返回值组合为一个
+// 清理函数 cleanup，调用时按照与返回值相反的顺序执行它们（nil 会被跳过）；
+// 即使没有这样的返回值，cleanup 也不会是 nil。
+func (c *Container) InvokeCleanup(fn any) (cleanup func(), results []reflect.Value, err error) {
+	results, err = c.Invoke(fn)
+	if err != nil {
+		return func() {}, nil, err
+	}
+	var funcs []func()
+	for _, r := range results {
+		if r.Type() == cleanupType && !r.IsNil() {
+			funcs = append(funcs, r.Interface().(func()))
+		}
+	}
+	cleanup = func() {
+		for i := len(funcs) - 1; i >= 0; i-- {
+			funcs[i]()
+		}
+	}
+	return cleanup, results, nil
+}
+
+// InvokeScan 执行指定的函数，并将其返回值依次赋值给 out 中对应的指针，
+// 若函数的最后一个返回值是 error 类型，则不需要为它提供指针，而是作为
+// InvokeScan 的错误返回。out 的数量与类型会在执行函数之前检查。
+func (c *Container) InvokeScan(fn any, out ...any) error {
+	rt := reflect.TypeOf(fn)
+	if err := checkFunc(rt); err != nil {
+		return err
+	}
+	n := rt.NumOut()
+	hasErr := n > 0 && rt.Out(n-1) == errorType
+	if hasErr {
+		n--
+	}
+	if len(out) != n {
+		return fmt.Errorf("ioc: %v returns %d value(s) but %d output(s) were given", rt, n, len(out))
+	}
+	targets := make([]reflect.Value, n)
+	for i, o := range out {
+		ov := reflect.ValueOf(o)
+		if ov.Kind() != reflect.Pointer || ov.IsNil() {
+			return fmt.Errorf("ioc: output %d must be a non-nil pointer, got %T", i+1, o)
+		}
+		if !rt.Out(i).AssignableTo(ov.Elem().Type()) {
+			return fmt.Errorf("ioc: return value %d (%v) of %v is not assignable to %v", i+1, rt.Out(i), rt, ov.Elem().Type())
+		}
+		targets[i] = ov.Elem()
+	}
+	results, err := c.invoke(context.Background(), rt, reflect.ValueOf(fn), c.invokeOptions())
+	if err != nil {
+		return err
+	}
+	for i, target := range targets {
+		target.Set(results[i])
+	}
+	if hasErr && !results[n].IsNil() {
+		return results[n].Interface().(error)
+	}
+	return nil
+}
+
+// invokeOptions 执行函数时的配置项
+type invokeOptions struct {
+	noConstruct bool // 不自动构建未绑定的结构体参数
+}
+
+// invokeOptions 返回用户直接调用函数（而非执行工厂函数）时使用的配置项
+func (c *Container) invokeOptions() invokeOptions {
+	return invokeOptions{noConstruct: c.opts.noInvokeAutoConstruct}
+}
+
+func (c *Container) invoke(ctx context.Context, rt reflect.Type, rv reflect.Value, iv invokeOptions, provided ...reflect.Value) ([]reflect.Value, error) {
 	var in = make([]reflect.Value, rt.NumIn())
+	var used = make([]bool, len(provided))
 	for i := 0; i < rt.NumIn(); i++ {
 		argType := rt.In(i)
-		val, err := c.Get(argType)
-		if err != nil {
-			return nil, err
+		if j := matchProvided(argType, provided, used); j >= 0 {
+			used[j] = true
+			in[i] = provided[j]
+			continue
 		}
-		if !val.IsValid() {
-			return nil, fmt.Errorf("ioc: value not found for type %v", argType)
+		if argType == contextType {
+			// context.Context 类型的参数使用解析过程所使用的上下文
+			in[i] = reflect.ValueOf(&ctx).Elem()
+			continue
+		}
+		if isInStruct(argType) {
+			// 参数对象总是被构建，不受 WithAutoConstructInvokeArgs 的影响
+			pv := reflect.New(argType)
+			if err := c.resolve(ctx, &pv, resolveOptions{}); err != nil {
+				return nil, fmt.Errorf("ioc: cannot resolve parameter %d (%v) of %v: %w", i+1, argType, rt, err)
+			}
+			in[i] = pv.Elem()
+			continue
+		}
+		val, err := c.find(ctx, "", argType, !iv.noConstruct)
+		if err == nil && !val.IsValid() {
+			err = ErrValueNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ioc: cannot resolve parameter %d (%v) of %v: %w", i+1, argType, rt, err)
 		}
 		in[i] = val
 	}
+	for j, ok := range used {
+		if !ok {
+			return nil, fmt.Errorf("ioc: provided argument %d (%v) matches no parameter of %v", j+1, provided[j].Type(), rt)
+		}
+	}
 	return rv.Call(in), nil
 }
 
+// checkFunc 检查类型是否为函数，不是时返回包装了 ErrNotFunc 的错误
+func checkFunc(rt reflect.Type) error {
+	if rt == nil {
+		return fmt.Errorf("%w: got nil", ErrNotFunc)
+	}
+	if rt.Kind() != reflect.Func {
+		return fmt.Errorf("%w: got %v of kind %v", ErrNotFunc, rt, rt.Kind())
+	}
+	return nil
+}
+
+// matchProvided 返回第一个未被使用且可以赋值给类型 t 的值的索引，找不到时返回 -1
+func matchProvided(t reflect.Type, provided []reflect.Value, used []bool) int {
+	for j, val := range provided {
+		if !used[j] && val.IsValid() && val.Type().AssignableTo(t) {
+			return j
+		}
+	}
+	return -1
+}
+
 // NewContext 返回一个被注入的服务容器的上下
 func (c *Container) NewContext(parentCtx ...context.Context) context.Context {
 	for _, ctx := range parentCtx {
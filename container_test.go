@@ -0,0 +1,1207 @@
+package ioc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type greeter interface {
+	Greet() string
+}
+
+type englishGreeter struct{}
+
+func (*englishGreeter) Greet() string { return "hello" }
+
+type chineseGreeter struct{}
+
+func (*chineseGreeter) Greet() string { return "你好" }
+
+type frenchGreeter struct{}
+
+func (frenchGreeter) Greet() string { return "bonjour" }
+
+func TestResolveInterfaceSlice(t *testing.T) {
+	c := New()
+	c.Bind(&englishGreeter{})
+	c.NamedBind("zh", &chineseGreeter{})
+
+	var s struct {
+		Greeters []greeter
+	}
+	if err := c.Resolve(&s); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, g := range s.Greeters {
+		got = append(got, g.Greet())
+	}
+	want := []string{"你好", "hello"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestResolveInterfaceSliceEmpty(t *testing.T) {
+	c := New()
+	var s struct {
+		Greeters []greeter
+	}
+	if err := c.Resolve(&s); err == nil {
+		t.Fatal("expected an error when nothing implements the interface")
+	}
+	var o struct {
+		Greeters []greeter `ioc:",omitempty"`
+	}
+	if err := c.Resolve(&o); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type paramA struct{}
+type paramB interface{ B() }
+type paramC struct{}
+
+func TestInvokeReportsParameterIndex(t *testing.T) {
+	c := New()
+	c.Bind(&paramA{})
+	c.Bind(&paramC{})
+	_, err := c.Invoke(func(a *paramA, b paramB, cc *paramC) {})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !errors.Is(err, ErrValueNotFound) {
+		t.Fatalf("error %v does not wrap ErrValueNotFound", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "parameter 2 (ioc.paramB)") {
+		t.Fatalf("error %q does not name the parameter", msg)
+	}
+}
+
+func TestResolveAll(t *testing.T) {
+	c := New()
+	c.Bind(&paramA{})
+	var ok struct {
+		A *paramA
+	}
+	var bad struct {
+		B paramB
+	}
+	var ok2 struct {
+		A *paramA
+	}
+	err := c.ResolveAll(&ok, &bad, &ok2)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if ok.A == nil || ok2.A == nil {
+		t.Fatal("resolvable targets were not resolved")
+	}
+	if msg := err.Error(); !strings.Contains(msg, "target 1") || strings.Contains(msg, "target 0") || strings.Contains(msg, "target 2") {
+		t.Fatalf("error %q does not name only the failed target", msg)
+	}
+	if !errors.Is(err, ErrValueNotFound) {
+		t.Fatalf("error %v does not wrap ErrValueNotFound", err)
+	}
+}
+
+type namedLogger struct{ prefix string }
+
+type fieldNameSvc struct{ ID int }
+
+func TestFieldNameAsName(t *testing.T) {
+	c := New(WithFieldNameAsName())
+	c.Bind(&namedLogger{prefix: "default"})
+	c.NamedBind("Logger", &namedLogger{prefix: "named"})
+	var s struct {
+		Logger *namedLogger
+		Other  *namedLogger
+	}
+	if err := c.Resolve(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Logger.prefix != "named" {
+		t.Fatalf("Logger got %q, want the value named after the field", s.Logger.prefix)
+	}
+	if s.Other.prefix != "default" {
+		t.Fatalf("Other got %q, want the unnamed value", s.Other.prefix)
+	}
+
+	// 未启用时忽略字段名称
+	var d struct {
+		Logger *namedLogger
+	}
+	plain := New()
+	plain.Bind(&namedLogger{prefix: "default"})
+	plain.NamedBind("Logger", &namedLogger{prefix: "named"})
+	if err := plain.Resolve(&d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Logger.prefix != "default" {
+		t.Fatalf("got %q without the option, want the unnamed value", d.Logger.prefix)
+	}
+}
+
+func TestFieldNameAsNameFallsBackToUnnamedStruct(t *testing.T) {
+	c := New(WithFieldNameAsName())
+	c.Bind(&fieldNameSvc{ID: 7})
+	var s struct {
+		S *fieldNameSvc
+	}
+	if err := c.Resolve(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.S.ID != 7 {
+		t.Fatalf("got ID %d, want the bound instance", s.S.ID)
+	}
+}
+
+func TestNamedPrimitives(t *testing.T) {
+	c := New()
+	c.NamedBind("httpTimeout", 30*time.Second)
+	c.NamedBind("port", 8080)
+	c.NamedBind("host", "localhost")
+	c.NamedBind("debug", true)
+	var s struct {
+		Timeout time.Duration `ioc:"httpTimeout"`
+		Port    int           `ioc:"port"`
+		Host    string        `ioc:"host"`
+		Debug   bool          `ioc:"debug"`
+	}
+	if err := c.Resolve(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Timeout != 30*time.Second || s.Port != 8080 || s.Host != "localhost" || !s.Debug {
+		t.Fatalf("got %+v", s)
+	}
+
+	// time.Duration 与其底层类型 int64 是不同的类型
+	var i struct {
+		Timeout int64 `ioc:"httpTimeout"`
+	}
+	if err := c.Resolve(&i); !errors.Is(err, ErrValueNotFound) {
+		t.Fatalf("got %v, want ErrValueNotFound for int64", err)
+	}
+}
+
+func TestWithParent(t *testing.T) {
+	plugin := New()
+	root := New()
+	root.Bind(&namedLogger{prefix: "root"})
+	plugin.WithParent(root)
+	val := mustGet(t, plugin, "", reflect.TypeOf(&namedLogger{}))
+	if val.Interface().(*namedLogger).prefix != "root" {
+		t.Fatal("value from the parent was not resolved")
+	}
+}
+
+func TestWithParentRejectsCycle(t *testing.T) {
+	a := New()
+	b := a.Fork()
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for a parent cycle")
+		}
+	}()
+	a.WithParent(b)
+}
+
+func TestCompose(t *testing.T) {
+	users := New()
+	users.Bind(&paramA{})
+	users.Bind(&namedLogger{prefix: "users"})
+	orders := New()
+	orders.Bind(&paramC{})
+	orders.Bind(&namedLogger{prefix: "orders"})
+
+	app := Compose(users, orders)
+	mustGet(t, app, "", reflect.TypeOf(&paramA{}))
+	mustGet(t, app, "", reflect.TypeOf(&paramC{}))
+	// 多个父容器都能提供时，以排在前面的为准
+	val := mustGet(t, app, "", reflect.TypeOf(&namedLogger{}))
+	if val.Interface().(*namedLogger).prefix != "users" {
+		t.Fatal("the first parent should win")
+	}
+}
+
+func TestBindType(t *testing.T) {
+	c := New()
+	gt := reflect.TypeOf((*greeter)(nil)).Elem()
+	if err := c.BindType(gt, &englishGreeter{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.NamedBindType("zh", gt, &chineseGreeter{}); err != nil {
+		t.Fatal(err)
+	}
+	if g := mustGet(t, c, "", gt).Interface().(greeter); g.Greet() != "hello" {
+		t.Fatalf("got %q", g.Greet())
+	}
+	if g := mustGet(t, c, "zh", gt).Interface().(greeter); g.Greet() != "你好" {
+		t.Fatalf("got %q", g.Greet())
+	}
+	// 值的类型本身并没有被绑定
+	if bindings := c.BindingsImplementing(reflect.TypeOf(&englishGreeter{})); len(bindings) != 0 {
+		t.Fatalf("unexpected bindings %v", bindings)
+	}
+	if err := c.BindType(gt, 42); err == nil {
+		t.Fatal("expected an error for a value that does not implement the interface")
+	}
+}
+
+type responseWriter interface{ Write(p []byte) (int, error) }
+
+type bufferWriter struct{ data []byte }
+
+func (w *bufferWriter) Write(p []byte) (int, error) {
+	w.data = append(w.data, p...)
+	return len(p), nil
+}
+
+func TestInvokeWith(t *testing.T) {
+	c := New()
+	c.Bind(&namedLogger{prefix: "injected"})
+	w := &bufferWriter{}
+	_, err := c.InvokeWith(func(logger *namedLogger, w responseWriter) {
+		_, _ = w.Write([]byte(logger.prefix))
+	}, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(w.data) != "injected" {
+		t.Fatalf("got %q", w.data)
+	}
+	if _, err := c.InvokeWith(func(*namedLogger) {}, w); err == nil {
+		t.Fatal("expected an error for a provided value that matches no parameter")
+	}
+	// 无类型的 nil 无法匹配参数，有类型的 nil 可以
+	if _, err := c.InvokeWith(func(*namedLogger, responseWriter) {}, w, nil); err == nil {
+		t.Fatal("expected an error for an untyped nil")
+	}
+	_, err = c.InvokeWith(func(logger *namedLogger) {
+		if logger != nil {
+			t.Fatal("the provided typed nil was not used")
+		}
+	}, (*namedLogger)(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFuncProviders(t *testing.T) {
+	c := New(WithFuncProviders())
+	calls := 0
+	if err := c.Factory(func() (*sharedService, error) { calls++; return &sharedService{n: calls}, nil }); err != nil {
+		t.Fatal(err)
+	}
+	var s struct {
+		New     func() (*sharedService, error)
+		MustNew func() *sharedService
+	}
+	if err := c.Resolve(&s); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 0 {
+		t.Fatal("factory ran before the closure was called")
+	}
+	svc, err := s.New()
+	if err != nil || svc.n != 1 {
+		t.Fatalf("got %v, %v", svc, err)
+	}
+	if svc := s.MustNew(); svc.n != 2 {
+		t.Fatalf("got n=%d, want a new instance", svc.n)
+	}
+
+	// 未启用时函数类型的字段无法解析
+	var d struct {
+		New func() (*sharedService, error)
+	}
+	plain := New()
+	if err := plain.Factory(func() *sharedService { return &sharedService{} }); err != nil {
+		t.Fatal(err)
+	}
+	if err := plain.Resolve(&d); !errors.Is(err, ErrValueNotFound) {
+		t.Fatalf("got %v, want ErrValueNotFound without the option", err)
+	}
+}
+
+func TestProvideNamed(t *testing.T) {
+	c := New()
+	err := c.ProvideNamed(map[string]any{
+		"a": func() *namedLogger { return &namedLogger{prefix: "a"} },
+		"b": func() *namedLogger { return &namedLogger{prefix: "b"} },
+		"c": func() *namedLogger { return &namedLogger{prefix: "c"} },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		val := mustGet(t, c, name, reflect.TypeOf(&namedLogger{}))
+		if got := val.Interface().(*namedLogger).prefix; got != name {
+			t.Fatalf("got %q for name %q", got, name)
+		}
+	}
+	err = c.ProvideNamed(map[string]any{"bad": 42, "worse": func() {}})
+	if err == nil || !strings.Contains(err.Error(), `"bad"`) || !strings.Contains(err.Error(), `"worse"`) {
+		t.Fatalf("got %v, want errors naming both invalid factories", err)
+	}
+}
+
+type missingDep interface{ Missing() }
+
+type innerNeedsMissing struct {
+	Dep missingDep
+}
+
+type outerNeedsInner struct {
+	Inner *innerNeedsMissing
+}
+
+func TestResolveErrorNamesFieldPath(t *testing.T) {
+	var o outerNeedsInner
+	err := New().Resolve(&o)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "outerNeedsInner.Inner") || !strings.Contains(msg, "innerNeedsMissing.Dep (ioc.missingDep)") {
+		t.Fatalf("error %q does not contain the resolution path", msg)
+	}
+}
+
+func TestZeroMissingPrimitives(t *testing.T) {
+	c := New(WithZeroMissingPrimitives())
+	s := struct {
+		Port    int     `ioc:"port"`
+		Host    string  `ioc:"host"`
+		Debug   bool    `ioc:"debug"`
+		Ratio   float64 `ioc:"ratio"`
+		Timeout time.Duration
+	}{Port: 1, Host: "x", Debug: true, Ratio: 1, Timeout: 1}
+	if err := c.Resolve(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Port != 0 || s.Host != "" || s.Debug || s.Ratio != 0 || s.Timeout != 0 {
+		t.Fatalf("got %+v, want zero values", s)
+	}
+	var i struct {
+		Port int
+		Dep  missingDep
+	}
+	if err := c.Resolve(&i); !errors.Is(err, ErrValueNotFound) {
+		t.Fatalf("got %v, want ErrValueNotFound for the interface field", err)
+	}
+}
+
+func TestNameResolver(t *testing.T) {
+	c := New(WithNameResolver(func(name string) string {
+		switch name {
+		case "database", "primary_db":
+			return "db"
+		}
+		return name
+	}))
+	c.NamedBind("database", &namedLogger{prefix: "db"})
+	var s struct {
+		A *namedLogger `ioc:"db"`
+		B *namedLogger `ioc:"database"`
+		C *namedLogger `ioc:"primary_db"`
+	}
+	if err := c.Resolve(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.A.prefix != "db" || s.A != s.B || s.B != s.C {
+		t.Fatal("aliases did not resolve to the same binding")
+	}
+}
+
+func TestAliasVisibleFromChild(t *testing.T) {
+	parent := New()
+	gt := reflect.TypeOf((*greeter)(nil)).Elem()
+	if err := parent.Alias(gt, reflect.TypeOf(&englishGreeter{})); err != nil {
+		t.Fatal(err)
+	}
+	child := parent.Fork()
+	// 没有任何绑定实现了接口，通过别名自动构建目标类型
+	g := mustGet(t, child, "", gt).Interface()
+	if _, ok := g.(*englishGreeter); !ok {
+		t.Fatalf("got %T through the alias", g)
+	}
+	if err := parent.Alias(gt, reflect.TypeOf(0)); err == nil {
+		t.Fatal("expected an error for a target that is not assignable")
+	}
+}
+
+func TestUnbindAll(t *testing.T) {
+	parent := New()
+	parent.Bind(&namedLogger{prefix: "parent"})
+	c := parent.Fork()
+	c.Bind(&namedLogger{prefix: "default"})
+	c.NamedBind("a", &namedLogger{prefix: "a"})
+	if err := c.NamedFactory("b", func() *namedLogger { return &namedLogger{prefix: "b"} }); err != nil {
+		t.Fatal(err)
+	}
+	c.Bind(&paramA{})
+	if n := c.UnbindAll(reflect.TypeOf(&namedLogger{})); n != 3 {
+		t.Fatalf("removed %d bindings, want 3", n)
+	}
+	if got := c.BindingsImplementing(reflect.TypeOf(&namedLogger{})); len(got) != 0 {
+		t.Fatalf("bindings left: %v", got)
+	}
+	// 父容器与其它类型的绑定不受影响
+	if val := mustGet(t, c, "", reflect.TypeOf(&namedLogger{})); val.Interface().(*namedLogger).prefix != "parent" {
+		t.Fatal("parent binding was affected")
+	}
+	mustGet(t, c, "", reflect.TypeOf(&paramA{}))
+}
+
+func TestInstanceAndFactoryPrecedence(t *testing.T) {
+	register := func(c *Container) {
+		c.Bind(&namedLogger{prefix: "instance"})
+		if err := c.Factory(func() *namedLogger { return &namedLogger{prefix: "factory"} }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	typ := reflect.TypeOf(&namedLogger{})
+
+	c := New()
+	register(c)
+	if got := mustGet(t, c, "", typ).Interface().(*namedLogger).prefix; got != "instance" {
+		t.Fatalf("got %q by default, want the instance", got)
+	}
+
+	f := New(WithFactoryPrecedence())
+	register(f)
+	if got := mustGet(t, f, "", typ).Interface().(*namedLogger).prefix; got != "factory" {
+		t.Fatalf("got %q with WithFactoryPrecedence, want the factory", got)
+	}
+}
+
+type defaultGreeter struct{}
+
+func (defaultGreeter) Greet() string { return "default" }
+
+func TestBindDefault(t *testing.T) {
+	gt := reflect.TypeOf((*greeter)(nil)).Elem()
+	c := New()
+	c.BindDefault((*greeter)(nil), defaultGreeter{})
+	if got := mustGet(t, c, "", gt).Interface().(greeter).Greet(); got != "default" {
+		t.Fatalf("got %q, want the default", got)
+	}
+	child := c.Fork()
+	child.Bind(&englishGreeter{})
+	if got := mustGet(t, child, "", gt).Interface().(greeter).Greet(); got != "hello" {
+		t.Fatalf("got %q, want the explicit binding", got)
+	}
+	if _, err := c.NamedGet("named", gt); !errors.Is(err, ErrValueNotFound) {
+		t.Fatalf("got %v, the default should not serve named lookups", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for a default that does not implement the interface")
+		}
+	}()
+	c.BindDefault((*greeter)(nil), 42)
+}
+
+func TestResolveMapAndSliceFields(t *testing.T) {
+	c := New()
+	c.NamedBind("en", &englishGreeter{})
+	c.NamedBind("zh", &chineseGreeter{})
+	var s struct {
+		ByName map[string]greeter
+		All    []greeter
+	}
+	if err := c.Resolve(&s); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.ByName) != 2 || s.ByName["en"].Greet() != "hello" || s.ByName["zh"].Greet() != "你好" {
+		t.Fatalf("got map %v", s.ByName)
+	}
+	if len(s.All) != 2 {
+		t.Fatalf("got %d slice elements, want 2", len(s.All))
+	}
+}
+
+type configValue[T any] struct{ value T }
+
+func TestFieldResolver(t *testing.T) {
+	typ := reflect.TypeOf(configValue[int]{})
+	c := New(WithFieldResolver(typ, func(c *Container, field reflect.StructField) (reflect.Value, error) {
+		return reflect.ValueOf(configValue[int]{value: len(field.Name)}), nil
+	}))
+	// 绑定的值不会被使用
+	c.Bind(configValue[int]{value: -1})
+	var s struct {
+		Port    configValue[int]
+		Timeout configValue[int]
+	}
+	if err := c.Resolve(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Port.value != 4 || s.Timeout.value != 7 {
+		t.Fatalf("got %+v, want values from the resolver", s)
+	}
+}
+
+func TestResolvePointerToPointerField(t *testing.T) {
+	c := New()
+	bound := &namedLogger{prefix: "bound"}
+	c.Bind(bound)
+	var s struct {
+		Logger **namedLogger
+		Auto   **paramA
+	}
+	if err := c.Resolve(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Logger == nil || *s.Logger != bound {
+		t.Fatal("pointer chain does not point at the bound value")
+	}
+	if s.Auto == nil || *s.Auto == nil {
+		t.Fatal("pointer chain for an unbound struct was not built")
+	}
+}
+
+func TestGetWithTimeout(t *testing.T) {
+	c := New()
+	release := make(chan struct{})
+	done := make(chan struct{})
+	if err := c.Factory(func() *sharedService {
+		defer close(done)
+		<-release
+		return &sharedService{}
+	}, true); err != nil {
+		t.Fatal(err)
+	}
+	_, err := c.GetWithTimeout(context.Background(), "", typeOf[*sharedService](), 10*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+	// 超时之后工厂函数依然在后台执行，完成之前不能使用容器
+	close(release)
+	<-done
+
+	fast := New()
+	fast.Bind(&namedLogger{prefix: "fast"})
+	val, err := fast.GetWithTimeout(context.Background(), "", typeOf[*namedLogger](), time.Second)
+	if err != nil || val.Interface().(*namedLogger).prefix != "fast" {
+		t.Fatalf("got %v, %v", val, err)
+	}
+}
+
+func TestResolveInto(t *testing.T) {
+	c := New()
+	c.Bind(&namedLogger{prefix: "container"})
+	c.NamedBind("port", 8080)
+	c.NamedBind("host", "container")
+	preset := &namedLogger{prefix: "preset"}
+	s := struct {
+		Logger *namedLogger
+		Port   int        `ioc:"port"`
+		Host   string     `ioc:"host"`
+		Dep    missingDep `ioc:",omitempty"`
+	}{Logger: preset, Port: 0, Host: "preset"}
+	if err := c.ResolveInto(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Logger != preset || s.Host != "preset" {
+		t.Fatal("explicitly set fields were overwritten")
+	}
+	if s.Port != 8080 {
+		t.Fatalf("zero field got %d, want the bound value", s.Port)
+	}
+	if s.Dep != nil {
+		t.Fatal("omitempty field should stay zero")
+	}
+}
+
+func TestGetAllIncludesFactories(t *testing.T) {
+	build := func(opts ...Option) *Container {
+		c := New(opts...)
+		c.Bind(&englishGreeter{})
+		if err := c.Factory(func() frenchGreeter { return frenchGreeter{} }); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Factory(func() *chineseGreeter { return &chineseGreeter{} }, true); err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	gt := reflect.TypeOf((*greeter)(nil)).Elem()
+	c := build()
+	values, err := c.GetAll(gt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range values {
+		got = append(got, v.Interface().(greeter).Greet())
+	}
+	if want := []string{"hello", "你好", "bonjour"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	// 共享的实例被缓存
+	if _, misses := c.CacheStats(); misses != 2 {
+		t.Fatalf("got %d factory runs, want 2", misses)
+	}
+	if _, err := c.GetAll(gt); err != nil {
+		t.Fatal(err)
+	}
+	if hits, _ := c.CacheStats(); hits != 1 {
+		t.Fatalf("got %d cache hits, want 1", hits)
+	}
+
+	// 不执行工厂函数时只收集已经构建的共享实例
+	s := build(WithCollectFactories(false))
+	if values, _ := s.GetAll(gt); len(values) != 1 {
+		t.Fatalf("got %d values without factories, want 1", len(values))
+	}
+	mustGet(t, s, "", typeOf[*chineseGreeter]())
+	if values, _ := s.GetAll(gt); len(values) != 2 {
+		t.Fatalf("got %d values after building the shared instance, want 2", len(values))
+	}
+}
+
+func TestBindBothNames(t *testing.T) {
+	c := New()
+	primary := &namedLogger{prefix: "primary"}
+	c.BindBothNames("primary", primary)
+	typ := typeOf[*namedLogger]()
+	if mustGet(t, c, "", typ).Interface() != primary || mustGet(t, c, "primary", typ).Interface() != primary {
+		t.Fatal("value not reachable by both names")
+	}
+}
+
+func TestInvokeNonFunction(t *testing.T) {
+	for _, fn := range []any{nil, 42, "func", struct{}{}} {
+		if _, err := New().Invoke(fn); !errors.Is(err, ErrNotFunc) {
+			t.Errorf("%T: got %v, want ErrNotFunc", fn, err)
+		}
+	}
+	if _, err := New().Invoke(42); !strings.Contains(err.Error(), "kind int") {
+		t.Errorf("error %q does not name the kind", err)
+	}
+}
+
+type requestIDKey struct{}
+
+func TestResolveContextField(t *testing.T) {
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-1")
+	var s struct {
+		Ctx    context.Context
+		Logger *namedLogger
+	}
+	if err := New().ResolveContext(ctx, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Ctx == nil || s.Ctx.Value(requestIDKey{}) != "req-1" {
+		t.Fatal("context field was not populated with the resolution context")
+	}
+}
+
+func TestResolveContextReachesFactories(t *testing.T) {
+	c := New()
+	if err := c.Factory(func(ctx context.Context) *namedLogger {
+		id, _ := ctx.Value(requestIDKey{}).(string)
+		return &namedLogger{prefix: id}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var s struct {
+		Logger *namedLogger
+	}
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-2")
+	if err := c.ResolveContext(ctx, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Logger.prefix != "req-2" {
+		t.Fatalf("factory got %q from the resolution context", s.Logger.prefix)
+	}
+}
+
+func TestNotFoundError(t *testing.T) {
+	_, err := New().NamedGet("primary", typeOf[missingDep]())
+	if !errors.Is(err, ErrValueNotFound) {
+		t.Fatalf("got %v, want ErrValueNotFound", err)
+	}
+	var nf *NotFoundError
+	if !errors.As(err, &nf) {
+		t.Fatalf("got %T, want *NotFoundError", err)
+	}
+	if nf.Type != typeOf[missingDep]() || nf.Name != "primary" {
+		t.Fatalf("got %+v", nf)
+	}
+	if want := `ioc: value not found for type ioc.missingDep named "primary"`; err.Error() != want {
+		t.Fatalf("got %q, want %q", err, want)
+	}
+}
+
+type endpoint struct{ URL string }
+
+func TestResolveStructSlices(t *testing.T) {
+	var empty struct {
+		Endpoints []endpoint `ioc:",omitempty"`
+	}
+	if err := New().Resolve(&empty); err != nil {
+		t.Fatal(err)
+	}
+	if len(empty.Endpoints) != 0 {
+		t.Fatalf("got %v, want an empty slice", empty.Endpoints)
+	}
+	var required struct {
+		Endpoints []endpoint
+	}
+	if err := New().Resolve(&required); !errors.Is(err, ErrValueNotFound) {
+		t.Fatalf("got %v, want ErrValueNotFound without omitempty", err)
+	}
+
+	c := New()
+	c.NamedBind("a", endpoint{URL: "a"})
+	c.NamedBind("b", endpoint{URL: "b"})
+	c.NamedBind("p", &endpoint{URL: "p"})
+	var s struct {
+		Endpoints []endpoint
+		Pointers  map[string]*endpoint
+	}
+	if err := c.Resolve(&s); err != nil {
+		t.Fatal(err)
+	}
+	if want := []endpoint{{"a"}, {"b"}}; !reflect.DeepEqual(s.Endpoints, want) {
+		t.Fatalf("got %v, want %v", s.Endpoints, want)
+	}
+	if len(s.Pointers) != 1 || s.Pointers["p"].URL != "p" {
+		t.Fatalf("got %v", s.Pointers)
+	}
+}
+
+type settings struct{ Level int }
+
+func TestPointerFieldFromBoundValue(t *testing.T) {
+	c := New()
+	c.Bind(settings{Level: 3})
+	var s struct {
+		Settings *settings
+	}
+	if err := c.Resolve(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Settings == nil || s.Settings.Level != 3 {
+		t.Fatalf("got %v", s.Settings)
+	}
+	// 注入的是副本，修改它不会影响绑定的值
+	s.Settings.Level = 5
+	if got := mustGet(t, c, "", typeOf[settings]()).Interface().(settings); got.Level != 3 {
+		t.Fatalf("bound value changed to %d", got.Level)
+	}
+}
+
+func TestAutoConstructInvokeArgs(t *testing.T) {
+	called := false
+	fn := func(a *paramA, cc paramC) { called = true }
+	if _, err := New().Invoke(fn); err != nil || !called {
+		t.Fatalf("got %v, want unbound structs to be constructed by default", err)
+	}
+	called = false
+	c := New(WithAutoConstructInvokeArgs(false))
+	if _, err := c.Invoke(fn); !errors.Is(err, ErrValueNotFound) || called {
+		t.Fatalf("got %v, want ErrValueNotFound when disabled", err)
+	}
+	// 工厂函数的参数不受影响
+	if err := c.Factory(func(a *paramA) *paramC { return &paramC{} }); err != nil {
+		t.Fatal(err)
+	}
+	mustGet(t, c, "", typeOf[*paramC]())
+}
+
+type nested[T any] struct{ Inner T }
+
+func TestMaxDepth(t *testing.T) {
+	typ := typeOf[nested[nested[nested[nested[paramC]]]]]()
+	if _, err := New().Get(typ); err != nil {
+		t.Fatalf("got %v with the default limit", err)
+	}
+	_, err := New(WithMaxDepth(3)).Get(typ)
+	if !errors.Is(err, ErrMaxDepthExceeded) {
+		t.Fatalf("got %v, want ErrMaxDepthExceeded", err)
+	}
+}
+
+func TestPointerAdaptation(t *testing.T) {
+	// 绑定了值而请求其指针，返回可寻址副本的指针
+	c := New()
+	c.Bind(endpoint{URL: "http://a"})
+	v, err := c.Get(typeOf[*endpoint]())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ep := v.Interface().(*endpoint)
+	ep.URL = "http://b"
+	if v, _ := c.Get(typeOf[endpoint]()); v.Interface().(endpoint).URL != "http://a" {
+		t.Fatal("modifying the adapted pointer changed the bound value")
+	}
+
+	// 绑定了指针而请求其值，仅在启用 WithPointerAdaptation 时适配
+	c = New()
+	c.Bind(&settings{Level: 3})
+	if _, err := c.Get(typeOf[settings]()); err == nil {
+		t.Fatal("want an error without WithPointerAdaptation")
+	}
+	c = New(WithPointerAdaptation())
+	c.Bind(&settings{Level: 3})
+	v, err = c.Get(typeOf[settings]())
+	if err != nil || v.Interface().(settings).Level != 3 {
+		t.Fatalf("got %v, %v", v, err)
+	}
+}
+
+func TestInvokeScan(t *testing.T) {
+	c := New()
+	c.Bind(&endpoint{URL: "http://a"})
+	var (
+		url string
+		g   greeter
+	)
+	err := c.InvokeScan(func(ep *endpoint) (string, *englishGreeter, error) {
+		return ep.URL, &englishGreeter{}, nil
+	}, &url, &g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "http://a" || g == nil || g.Greet() != "hello" {
+		t.Fatalf("got %q, %v", url, g)
+	}
+
+	// 数量与类型不匹配时不执行函数
+	called := false
+	fn := func() (string, int) { called = true; return "", 0 }
+	if err := c.InvokeScan(fn, &url); err == nil {
+		t.Error("want an error for a missing output")
+	}
+	var s string
+	if err := c.InvokeScan(fn, &url, &s); err == nil {
+		t.Error("want an error for a mismatched output type")
+	}
+	if err := c.InvokeScan(fn, url, &s); err == nil {
+		t.Error("want an error for a non-pointer output")
+	}
+	if called {
+		t.Error("the function ran despite invalid outputs")
+	}
+
+	// 函数返回的错误作为 InvokeScan 的错误返回
+	errFailed := errors.New("failed")
+	if err := c.InvokeScan(func() (string, error) { return "", errFailed }, &url); !errors.Is(err, errFailed) {
+		t.Fatalf("got %v, want the function's error", err)
+	}
+}
+
+type endpointUser struct {
+	Endpoint *endpoint
+}
+
+func TestResolveValue(t *testing.T) {
+	c := New()
+	ep := &endpoint{URL: "http://a"}
+	c.Bind(ep)
+	in := endpointUser{}
+	if err := c.Resolve(in); err == nil {
+		t.Fatal("want an error for a struct passed by value")
+	}
+	out, err := c.ResolveValue(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.(endpointUser); got.Endpoint != ep {
+		t.Fatalf("got %+v", got)
+	}
+	if in.Endpoint != nil {
+		t.Fatal("ResolveValue modified its argument")
+	}
+	if _, err := c.ResolveValue(&in); err == nil {
+		t.Fatal("want an error for a pointer passed to ResolveValue")
+	}
+}
+
+func TestInvokeContext(t *testing.T) {
+	c := New()
+	ep := &endpoint{URL: "http://a"}
+	c.Bind(ep)
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-1")
+	out, err := c.InvokeContext(ctx, func(ctx context.Context, got *endpoint) string {
+		if got != ep {
+			return "wrong endpoint"
+		}
+		id, _ := ctx.Value(requestIDKey{}).(string)
+		return id
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out[0].String(); got != "req-1" {
+		t.Fatalf("got %q, want the supplied context to be injected", got)
+	}
+}
+
+func TestResolveWithFallback(t *testing.T) {
+	root := New()
+	root.Bind(&endpoint{URL: "http://root"})
+	root.NamedBind("level", 1)
+	request := New()
+	request.NamedBind("level", 2)
+	request.Bind(&englishGreeter{})
+	var s struct {
+		Endpoint *endpoint
+		Level    int `ioc:"level"`
+		Greeter  *englishGreeter
+	}
+	if err := request.ResolveWithFallback(&s, root); err != nil {
+		t.Fatal(err)
+	}
+	if s.Endpoint == nil || s.Endpoint.URL != "http://root" {
+		t.Fatalf("got endpoint %v, want it from the fallback", s.Endpoint)
+	}
+	if s.Level != 2 || s.Greeter == nil {
+		t.Fatalf("got level %d and greeter %v, want them from the primary", s.Level, s.Greeter)
+	}
+}
+
+type app struct{ name string }
+
+func (a *app) Run(ep *endpoint, level int) string {
+	return fmt.Sprintf("%s %s %d", a.name, ep.URL, level)
+}
+
+func TestResolveMethod(t *testing.T) {
+	c := New()
+	c.Bind(&app{name: "demo"})
+	c.Bind(&endpoint{URL: "http://a"})
+	c.Bind(7)
+	out, err := c.ResolveMethod(typeOf[*app](), "Run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out[0].String(); got != "demo http://a 7" {
+		t.Fatalf("got %q", got)
+	}
+	if _, err := c.ResolveMethod(typeOf[*app](), "Missing"); err == nil {
+		t.Fatal("want an error for a missing method")
+	}
+}
+
+type setterService struct {
+	logger      *namedLogger
+	deadlineSet bool
+}
+
+func (s *setterService) SetLogger(l *namedLogger) { s.logger = l }
+
+func (s *setterService) SetDeadline(time.Time) { s.deadlineSet = true }
+
+func TestSetterInjection(t *testing.T) {
+	c := New(WithSetterInjection())
+	l := &namedLogger{prefix: "app"}
+	c.Bind(l)
+	var s setterService
+	if err := c.Resolve(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.logger != l {
+		t.Fatal("SetLogger was not called with the bound logger")
+	}
+	// 未绑定的结构体不会被自动构建并传入 setter
+	if s.deadlineSet {
+		t.Fatal("SetDeadline was called with an auto-constructed zero value")
+	}
+	c.Bind(time.Now())
+	if err := c.Resolve(&s); err != nil || !s.deadlineSet {
+		t.Fatalf("got %v, want SetDeadline called once time.Time is bound", err)
+	}
+
+	// 默认不启用
+	s = setterService{}
+	if err := New().Resolve(&s); err != nil || s.logger != nil {
+		t.Fatalf("got %v, want setters ignored without WithSetterInjection", err)
+	}
+}
+
+// embeddedLoggerService 嵌入了 *log.Logger，其 SetPrefix 等方法被提升
+type embeddedLoggerService struct {
+	*log.Logger
+	logger      *namedLogger
+	setupCalled bool
+}
+
+func (s *embeddedLoggerService) SetLogger(l *namedLogger) { s.logger = l }
+
+func (s *embeddedLoggerService) Setup(*namedLogger) { s.setupCalled = true }
+
+func TestSetterInjectionSkipsPromotedAndNonSetters(t *testing.T) {
+	c := New(WithSetterInjection())
+	var out strings.Builder
+	logger := log.New(&out, "app: ", 0)
+	c.Bind(logger)
+	l := &namedLogger{prefix: "app"}
+	c.Bind(l)
+	// 这些绑定可以满足 SetPrefix 与 SetOutput 的参数
+	c.Bind("hijacked: ")
+	if err := c.BindType(typeOf[io.Writer](), io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	var s embeddedLoggerService
+	if err := c.Resolve(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Logger != logger || s.logger != l {
+		t.Fatal("fields or setters were not injected")
+	}
+	if logger.Prefix() != "app: " || logger.Writer() != &out {
+		t.Fatal("methods promoted from the embedded *log.Logger were called")
+	}
+	if s.setupCalled {
+		t.Fatal("Setup is not a setter")
+	}
+	for name, want := range map[string]bool{"SetLogger": true, "SetX": true, "Set": false, "Setup": false, "Settle": false, "Settings": false} {
+		if got := isSetterName(name); got != want {
+			t.Errorf("isSetterName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+type selfNamedPlugin struct {
+	Name     string `ioc:",name"`
+	Endpoint *endpoint
+}
+
+func TestResolvedNameField(t *testing.T) {
+	c := New()
+	c.Bind(&endpoint{URL: "http://a"})
+	for _, name := range []string{"foo", "bar", ""} {
+		p := mustGet(t, c, name, typeOf[*selfNamedPlugin]()).Interface().(*selfNamedPlugin)
+		if p.Name != name || p.Endpoint == nil {
+			t.Fatalf("got %+v, want the name %q", p, name)
+		}
+	}
+	var bad struct {
+		Name int `ioc:",name"`
+	}
+	if err := c.Resolve(&bad); err == nil {
+		t.Fatal("want an error for a non-string name field")
+	}
+}
+
+func TestRequiredFieldUnderSoftResolve(t *testing.T) {
+	c := New(WithSoftResolve())
+	c.Bind(&endpoint{URL: "http://a"})
+	var soft struct {
+		Endpoint *endpoint
+		Missing  missingDep
+	}
+	if err := c.Resolve(&soft); err != nil || soft.Endpoint == nil || soft.Missing != nil {
+		t.Fatalf("got %+v, %v, want the missing field skipped", soft, err)
+	}
+	var hard struct {
+		Endpoint *endpoint
+		Optional missingDep
+		Required missingDep `ioc:",required"`
+	}
+	if err := c.Resolve(&hard); !errors.Is(err, ErrValueNotFound) {
+		t.Fatalf("got %v, want the required field to fail", err)
+	}
+}
+
+func TestInvokeCleanup(t *testing.T) {
+	c := New()
+	c.Bind(&endpoint{URL: "http://a"})
+	var log []string
+	cleanup, results, err := c.InvokeCleanup(func(ep *endpoint) (func(), string, func(), func()) {
+		return func() { log = append(log, "first") },
+			ep.URL,
+			nil,
+			func() { log = append(log, "second") }
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 || results[1].String() != "http://a" {
+		t.Fatalf("got results %v", results)
+	}
+	if len(log) != 0 {
+		t.Fatal("cleanups ran before cleanup was called")
+	}
+	cleanup()
+	if want := []string{"second", "first"}; !reflect.DeepEqual(log, want) {
+		t.Fatalf("got %v, want %v", log, want)
+	}
+	cleanup, _, err = c.InvokeCleanup(func() {})
+	if err != nil || cleanup == nil {
+		t.Fatalf("got %v, want a non-nil cleanup", err)
+	}
+	cleanup()
+}
+
+func TestDefaultName(t *testing.T) {
+	root := New()
+	root.Bind(&endpoint{URL: "http://shared"})
+	root.NamedBind("tenant-a", &endpoint{URL: "http://a"})
+	root.Bind(&namedLogger{prefix: "shared"})
+	root.NamedBind("tenant-b", &namedLogger{prefix: "b"})
+	tenant := root.Fork(WithDefaultName("tenant-a"))
+
+	var s struct {
+		Endpoint *endpoint
+		Logger   *namedLogger
+		Other    *namedLogger `ioc:"tenant-b"`
+	}
+	if err := tenant.Resolve(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Endpoint.URL != "http://a" {
+		t.Fatalf("got %q, want the tenant binding", s.Endpoint.URL)
+	}
+	if s.Logger.prefix != "shared" {
+		t.Fatalf("got %q, want the unnamed binding without a tenant one", s.Logger.prefix)
+	}
+	if s.Other.prefix != "b" {
+		t.Fatalf("got %q, want the explicit name to override", s.Other.prefix)
+	}
+	if v := mustGet(t, root, "", typeOf[*endpoint]()); v.Interface().(*endpoint).URL != "http://shared" {
+		t.Fatal("the default name leaked into the parent container")
+	}
+}
+
+func TestNameFallback(t *testing.T) {
+	factory := func() *database { return &database{addr: "unnamed"} }
+	var s struct {
+		DB *database `ioc:"primary"`
+	}
+	c := New()
+	if err := c.Factory(factory); err != nil {
+		t.Fatal(err)
+	}
+	// 未启用时具名字段不会使用匿名的工厂函数（database 只能自动构建）
+	if err := c.Resolve(&s); err != nil || s.DB.addr != "" {
+		t.Fatalf("got %+v, %v, want the factory ignored", s.DB, err)
+	}
+
+	c = New(WithNameFallback())
+	if err := c.Factory(factory); err != nil {
+		t.Fatal(err)
+	}
+	s.DB = nil
+	if err := c.Resolve(&s); err != nil || s.DB.addr != "unnamed" {
+		t.Fatalf("got %+v, %v, want the unnamed factory", s.DB, err)
+	}
+	// 存在具名的绑定时依然优先使用
+	c.NamedBind("primary", &database{addr: "primary"})
+	s.DB = nil
+	if err := c.Resolve(&s); err != nil || s.DB.addr != "primary" {
+		t.Fatalf("got %+v, %v, want the named binding", s.DB, err)
+	}
+}
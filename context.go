@@ -0,0 +1,45 @@
+package ioc
+
+import (
+	"context"
+	"reflect"
+)
+
+// contextBinding 根据上下文选择的绑定
+type contextBinding struct {
+	key   any
+	match func(v any) bool
+	value reflect.Value
+}
+
+// BindForContext 绑定一个根据上下文选择的“具体实现”，当通过上下文解析依赖时
+// （如 GetContext、NamedGet[T] 等），若上下文中键 key 对应的值满足 match，
+// 该值将优先于其它的绑定被使用，适用于多租户等场景。
+//
+// 此类绑定只参与匿名（名称为空）的查找，同一类型存在多个满足条件的绑定时，
+// 使用最先绑定的那个。
+func (c *Container) BindForContext(key any, match func(v any) bool, value any) {
+	rv := reflect.ValueOf(value)
+	if !rv.IsValid() {
+		return
+	}
+	c.contexts = append(c.contexts, contextBinding{key: key, match: match, value: rv})
+}
+
+// lookupContext 沿着父容器链查找与上下文匹配的绑定
+func (c *Container) lookupContext(ctx context.Context, name string, t reflect.Type) (reflect.Value, bool) {
+	if ctx == nil || name != "" {
+		return reflect.Value{}, false
+	}
+	for _, ci := range c.lineage() {
+		for _, cb := range ci.contexts {
+			if !cb.value.Type().AssignableTo(t) {
+				continue
+			}
+			if v := ctx.Value(cb.key); v != nil && cb.match(v) {
+				return cb.value, true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
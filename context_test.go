@@ -0,0 +1,37 @@
+package ioc
+
+import (
+	"context"
+	"testing"
+)
+
+type tenantKey struct{}
+
+func TestBindForContext(t *testing.T) {
+	c := New()
+	c.Bind(&namedLogger{prefix: "default"})
+	c.BindForContext(tenantKey{}, func(v any) bool { return v == "acme" }, &namedLogger{prefix: "acme"})
+	c.BindForContext(tenantKey{}, func(v any) bool { return v == "globex" }, &namedLogger{prefix: "globex"})
+
+	for tenant, want := range map[string]string{"acme": "acme", "globex": "globex", "other": "default"} {
+		ctx := context.WithValue(context.Background(), tenantKey{}, tenant)
+		val, err := c.GetContext(ctx, typeOf[*namedLogger]())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := val.Interface().(*namedLogger).prefix; got != want {
+			t.Fatalf("tenant %q got %q, want %q", tenant, got, want)
+		}
+	}
+	// 通过上下文注入结构体同样有效
+	var s struct {
+		Logger *namedLogger
+	}
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	if err := c.ResolveContext(ctx, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Logger.prefix != "acme" {
+		t.Fatalf("got %q", s.Logger.prefix)
+	}
+}
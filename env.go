@@ -0,0 +1,22 @@
+package ioc
+
+import (
+	"os"
+	"strings"
+)
+
+// LoadEnv 将以 prefix 为前缀的环境变量以字符串的形式绑定到容器中，
+// 如 PREFIX_DB_HOST=localhost 将被绑定为名称为 db_host 的字符串，
+// 前缀为空时绑定所有的环境变量。
+func (c *Container) LoadEnv(prefix string) {
+	if prefix != "" && !strings.HasSuffix(prefix, "_") {
+		prefix += "_"
+	}
+	for _, env := range os.Environ() {
+		key, value, ok := strings.Cut(env, "=")
+		if !ok || !strings.HasPrefix(key, prefix) || len(key) == len(prefix) {
+			continue
+		}
+		c.NamedBind(strings.ToLower(key[len(prefix):]), value)
+	}
+}
@@ -0,0 +1,27 @@
+package ioc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLoadEnv(t *testing.T) {
+	t.Setenv("IOCTEST_DB_HOST", "localhost")
+	t.Setenv("IOCTEST_PORT", "5432")
+	t.Setenv("OTHER_PORT", "80")
+	c := New()
+	c.LoadEnv("IOCTEST")
+	var s struct {
+		Host string `ioc:"db_host"`
+		Port string `ioc:"port"`
+	}
+	if err := c.Resolve(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Host != "localhost" || s.Port != "5432" {
+		t.Fatalf("got %+v", s)
+	}
+	if _, err := c.NamedGet("other_port", reflect.TypeOf("")); err == nil {
+		t.Fatal("variables without the prefix should not be bound")
+	}
+}
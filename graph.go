@@ -0,0 +1,371 @@
+package ioc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ErrCircularDependency 工厂函数之间存在（间接的）循环依赖
+var ErrCircularDependency = errors.New("ioc: circular dependency")
+
+// checkCycle 检查注册绑定 b 之后，工厂函数之间是否会形成循环依赖，
+// 即解析 b 的某个参数时需要再次构建 b 的返回值。
+//
+// 依赖关系与获取时的查找步骤一致：参数由绑定的值满足时没有后续的依赖；
+// 当参数是接口且需要扫描工厂函数时，所有能够满足它的工厂函数都会被视为
+// 可能的依赖，但有多个这样的工厂函数时，b 本身不会被视为依赖（获取时
+// 会返回 ErrAmbiguousBinding 而不是构建 b），因此装饰器等工厂函数可以
+// 依赖其返回值所实现的接口。
+func (c *Container) checkCycle(b *binding) error {
+	path := []reflect.Type{b.typ}
+	visited := make(map[*binding]bool)
+	var visit func(bind *binding) bool
+	visit = func(bind *binding) bool {
+		ft := bind.factory.Type()
+		for i := 0; i < ft.NumIn(); i++ {
+			t := ft.In(i)
+			path = append(path, t)
+			for _, next := range c.factoryProviders(t, b) {
+				if next.typ != t {
+					path = append(path, next.typ)
+				}
+				if next == b {
+					return true
+				}
+				if !visited[next] {
+					visited[next] = true
+					if visit(next) {
+						return true
+					}
+				}
+				if next.typ != t {
+					path = path[:len(path)-1]
+				}
+			}
+			path = path[:len(path)-1]
+		}
+		return false
+	}
+	if visit(b) {
+		names := make([]string, len(path))
+		for j, t := range path {
+			names[j] = t.String()
+		}
+		return fmt.Errorf("%w: %s", ErrCircularDependency, strings.Join(names, " -> "))
+	}
+	return nil
+}
+
+// factoryProviders 返回匿名获取类型 t 时本容器中可能执行的工厂函数，查找顺序
+// 与 lookup 一致，由绑定的值满足时返回空；正在注册的绑定 b 被视为已经注册。
+func (c *Container) factoryProviders(t reflect.Type, b *binding) []*binding {
+	factoryOf := func(rt reflect.Type) *binding {
+		if rt == b.typ && b.name == "" {
+			return b
+		}
+		return c.factories[rt][""]
+	}
+	_, instanced := c.instances[t][""]
+	exact := factoryOf(t)
+	if exact != nil && !exact.reachableAs(t) {
+		exact = nil
+	}
+	if exact != nil && (c.opts.factoryPrecedence || !instanced) {
+		return []*binding{exact}
+	}
+	if instanced {
+		return nil
+	}
+	for rt, values := range c.instances {
+		if _, ok := values[""]; ok && rt != t && rt.AssignableTo(t) {
+			return nil
+		}
+	}
+	types := make([]reflect.Type, 0, len(c.factories)+1)
+	for rt := range c.factories {
+		types = append(types, rt)
+	}
+	if _, ok := c.factories[b.typ]; !ok {
+		types = append(types, b.typ)
+	}
+	sortTypes(types)
+	var result []*binding
+	for _, rt := range types {
+		if bind := factoryOf(rt); bind != nil && rt != t && rt.AssignableTo(t) && bind.reachableAs(t) {
+			result = append(result, bind)
+		}
+	}
+	if len(result) > 1 {
+		// 有多个工厂函数时获取会失败，因此 b 本身不会被构建
+		result = slices.DeleteFunc(result, func(bind *binding) bool { return bind == b })
+	}
+	return result
+}
+
+// Unsatisfied 描述一个依赖无法被满足的工厂函数
+type Unsatisfied struct {
+	Type           reflect.Type // 工厂函数返回的类型
+	Name           string       // 工厂函数绑定的名称
+	Factory        reflect.Type // 工厂函数的签名
+	Dependency     reflect.Type // 无法满足的参数类型，对于参数对象是其中无法满足的字段类型
+	DependencyName string       // 参数对象中无法满足的字段所要求的绑定名称
+}
+
+// HealthCheck 检查本容器中所有的工厂函数，返回其中依赖无法被满足的项，
+// 检查只根据当前的绑定情况静态地进行，不会执行任何工厂函数。
+func (c *Container) HealthCheck() []Unsatisfied {
+	var result []Unsatisfied
+	types := make([]reflect.Type, 0, len(c.factories))
+	for rt := range c.factories {
+		types = append(types, rt)
+	}
+	sortTypes(types)
+	for _, rt := range types {
+		bindings := c.factories[rt]
+		for _, name := range sortedKeys(bindings) {
+			ft := bindings[name].factory.Type()
+			for i := 0; i < ft.NumIn(); i++ {
+				if isInStruct(ft.In(i)) {
+					// 参数对象逐个检查其字段，以便报告具体缺失的（具名）依赖
+					for _, field := range inFields(ft.In(i)) {
+						tag := parseTag(field)
+						if !c.optional(tag) && !tag.resolvedName && !c.canResolve(tag.name, field.Type, make(map[reflect.Type]bool)) {
+							result = append(result, Unsatisfied{
+								Type:           rt,
+								Name:           name,
+								Factory:        ft,
+								Dependency:     field.Type,
+								DependencyName: tag.name,
+							})
+						}
+					}
+					continue
+				}
+				if !c.canResolve("", ft.In(i), make(map[reflect.Type]bool)) {
+					result = append(result, Unsatisfied{
+						Type:       rt,
+						Name:       name,
+						Factory:    ft,
+						Dependency: ft.In(i),
+					})
+				}
+			}
+		}
+	}
+	return result
+}
+
+// canResolve 静态地判断类型 t 是否能够被获取，与 get 的查找步骤一致，
+// 但对于工厂函数只检查其是否存在而不检查其依赖。
+func (c *Container) canResolve(name string, t reflect.Type, seen map[reflect.Type]bool) bool {
+	name = c.canonicalName(name)
+	for _, ci := range c.lineage() {
+		for rt, values := range ci.instances {
+			if _, ok := values[name]; ok && rt.AssignableTo(t) {
+				return true
+			}
+		}
+		for rt, bindings := range ci.factories {
+			if b, ok := bindings[name]; ok && rt.AssignableTo(t) && b.reachableAs(t) {
+				return true
+			}
+		}
+	}
+	if target := c.aliasOf(t); target != nil {
+		return c.canResolve(name, target, seen)
+	}
+	if _, ok := c.lookupDefault(name, t); ok {
+		return true
+	}
+	if c.opts.funcProviders && c.hasProvider(name, t) {
+		return true
+	}
+	if name == "" && isCollection(t) && len(c.BindingsImplementing(t.Elem(), true)) > 0 {
+		return true
+	}
+	st := t
+	if st.Kind() == reflect.Pointer {
+		st = st.Elem()
+	}
+	if st.Kind() != reflect.Struct || seen[st] {
+		return false
+	}
+	seen[st] = true
+	defer delete(seen, st)
+	for i := 0; i < st.NumField(); i++ {
+		field := st.Field(i)
+		tag := parseTag(field)
+		if c.optional(tag) || tag.resolvedName || !field.IsExported() {
+			continue
+		}
+		if !c.canResolve(tag.name, field.Type, seen) {
+			return false
+		}
+	}
+	return true
+}
+
+// ResolvedType 静态地返回获取类型 t 与名称 name 时将得到的“具体实现”的实际类型，
+// 不会执行任何工厂函数：绑定的值返回其实际类型；工厂函数返回其已经构建的共享实例
+// 的实际类型，尚未构建时返回其声明的返回值类型；能够自动构建的结构体（或结构体指针）
+// 返回其本身。找不到时返回 false。
+//
+// 查找的顺序与 Get 一致，包括默认名称（WithDefaultName）、名称回退
+// （WithNameFallback）、别名、默认实现、函数提供者，以及多级指针与指针的适配，
+// 唯一的例外是与上下文匹配的绑定（BindForContext），由于没有上下文，它们不会
+// 被考虑，通过 GetContext 获取时的结果可能与此不同。
+func (c *Container) ResolvedType(name string, t reflect.Type) (reflect.Type, bool) {
+	if t == nil {
+		return nil, false
+	}
+	name = c.canonicalName(name)
+	if name == "" && c.opts.defaultName != "" {
+		// 未指定名称时优先使用以默认名称绑定的值，没有时再使用匿名绑定
+		if rt, ok := c.lookupType(c.canonicalName(c.opts.defaultName), t); ok {
+			return rt, true
+		}
+	}
+	if rt, ok := c.lookupType(name, t); ok {
+		return rt, true
+	}
+	if name != "" && c.opts.nameFallback {
+		if rt, ok := c.lookupType("", t); ok {
+			return rt, true
+		}
+	}
+	if target := c.aliasOf(t); target != nil {
+		return c.ResolvedType(name, target)
+	}
+	if val, ok := c.lookupDefault(name, t); ok {
+		return concreteType(val), true
+	}
+	if c.opts.funcProviders && c.hasProvider(name, t) {
+		return t, true
+	}
+	// 多级指针与绑定了元素类型的指针，得到的都是新分配的指针
+	if t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Pointer {
+		if _, ok := c.ResolvedType(name, t.Elem()); ok {
+			return t, true
+		}
+	}
+	if t.Kind() == reflect.Pointer {
+		if _, ok := c.lookupType(name, t.Elem()); ok {
+			return t, true
+		}
+	}
+	if c.opts.pointerAdaptation && t.Kind() != reflect.Pointer {
+		if rt, ok := c.lookupType(name, reflect.PointerTo(t)); ok && rt.Kind() == reflect.Pointer {
+			return rt.Elem(), true
+		}
+	}
+	if c.hidden(name, t) {
+		return nil, false
+	}
+	if t.Kind() == reflect.Struct || t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct {
+		return t, true
+	}
+	return nil, false
+}
+
+// lookupType 沿着父容器链查找类型 t 与名称 name 对应的“具体实现”的实际类型，
+// 与 lookup 一样不考虑别名、默认实现等。
+func (c *Container) lookupType(name string, t reflect.Type) (reflect.Type, bool) {
+	for _, ci := range c.lineage() {
+		if rt, ok := ci.resolvedType(name, t); ok {
+			return rt, true
+		}
+	}
+	return nil, false
+}
+
+// resolvedType 在本容器中查找类型 t 与名称 name 对应的“具体实现”的实际类型，
+// 查找顺序与 lookup 一致。
+func (c *Container) resolvedType(name string, t reflect.Type) (reflect.Type, bool) {
+	instance := func() (reflect.Type, bool) {
+		if val, ok := c.instances[t][name]; ok && val.IsValid() {
+			return concreteType(val), true
+		}
+		return nil, false
+	}
+	factory := func() (reflect.Type, bool) {
+		if b, ok := c.factories[t][name]; ok && b.reachableAs(t) {
+			return b.concreteType(), true
+		}
+		return nil, false
+	}
+	first, second := instance, factory
+	if c.opts.factoryPrecedence {
+		first, second = factory, instance
+	}
+	if rt, ok := first(); ok {
+		return rt, true
+	}
+	if rt, ok := second(); ok {
+		return rt, true
+	}
+
+	// 扫描的结果不唯一时，获取会返回 ErrAmbiguousBinding，因此视为找不到
+	var types []reflect.Type
+	for rt, values := range c.instances {
+		if val, ok := values[name]; ok && val.IsValid() && rt != t && rt.AssignableTo(t) {
+			types = append(types, rt)
+		}
+	}
+	types = c.distinctInstances(name, types)
+	if len(types) == 1 {
+		return concreteType(c.instances[types[0]][name]), true
+	}
+	if len(types) > 1 {
+		return nil, false
+	}
+	for rt, bindings := range c.factories {
+		if b, ok := bindings[name]; ok && rt != t && rt.AssignableTo(t) && b.reachableAs(t) {
+			types = append(types, rt)
+		}
+	}
+	if len(types) == 1 {
+		return c.factories[types[0]][name].concreteType(), true
+	}
+	return nil, false
+}
+
+// GraphDOT 以 Graphviz DOT 格式导出本容器的依赖关系图，每个绑定的值与工厂函数
+// 都是一个节点，工厂函数与其参数类型之间是一条边，工厂函数节点会标明其构建
+// 的实例是否共享（shared）或临时（transient）。
+func (c *Container) GraphDOT() string {
+	var sb strings.Builder
+	sb.WriteString("digraph ioc {\n")
+	var edges []string
+	for _, info := range c.bindings() {
+		id := nodeID(info.Type, info.Name)
+		if !info.Factory {
+			fmt.Fprintf(&sb, "\t%q [shape=box, label=%q];\n", id, id+"\ninstance")
+			continue
+		}
+		lifetime := "transient"
+		if info.Shared {
+			lifetime = "shared"
+		}
+		fmt.Fprintf(&sb, "\t%q [label=%q];\n", id, id+"\n"+lifetime)
+		ft := c.factories[info.Type][info.Name].factory.Type()
+		for i := 0; i < ft.NumIn(); i++ {
+			edges = append(edges, fmt.Sprintf("\t%q -> %q;\n", id, nodeID(ft.In(i), "")))
+		}
+	}
+	for _, edge := range edges {
+		sb.WriteString(edge)
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// nodeID 返回绑定在依赖关系图中的节点名称
+func nodeID(t reflect.Type, name string) string {
+	if name == "" {
+		return t.String()
+	}
+	return fmt.Sprintf("%v[%s]", t, name)
+}
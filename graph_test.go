@@ -0,0 +1,227 @@
+package ioc
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type cycleA struct{}
+type cycleB struct{}
+type cycleC struct{}
+
+func TestCheckCycle(t *testing.T) {
+	c := New()
+	if err := c.Factory(func(*cycleB) *cycleA { return &cycleA{} }); err != nil {
+		t.Fatal(err)
+	}
+	err := c.Factory(func(*cycleA) *cycleB { return &cycleB{} })
+	if !errors.Is(err, ErrCircularDependency) {
+		t.Fatalf("got %v, want ErrCircularDependency", err)
+	}
+	if !strings.Contains(err.Error(), "*ioc.cycleB -> *ioc.cycleA -> *ioc.cycleB") {
+		t.Fatalf("error %q does not name the cycle", err)
+	}
+}
+
+func TestCheckIndirectCycle(t *testing.T) {
+	c := New()
+	if err := c.Factory(func(*cycleB) *cycleA { return &cycleA{} }); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Factory(func(*cycleC) *cycleB { return &cycleB{} }); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Factory(func(*cycleA) *cycleC { return &cycleC{} }); !errors.Is(err, ErrCircularDependency) {
+		t.Fatalf("got %v, want ErrCircularDependency", err)
+	}
+}
+
+type cycleLogger interface{ Log(string) }
+
+type consoleLogger struct{}
+
+func (*consoleLogger) Log(string) {}
+
+type prefixLogger struct{ base cycleLogger }
+
+func (l *prefixLogger) Log(s string) { l.base.Log("> " + s) }
+
+type loggerUser struct{ logger cycleLogger }
+
+func TestCheckCycleThroughInterface(t *testing.T) {
+	c := New()
+	if err := c.Factory(func(l cycleLogger) *loggerUser { return &loggerUser{l} }); err != nil {
+		t.Fatal(err)
+	}
+	// 唯一实现了接口的工厂函数依赖于接口的使用者
+	err := c.Factory(func(*loggerUser) *prefixLogger { return &prefixLogger{} })
+	if !errors.Is(err, ErrCircularDependency) {
+		t.Fatalf("got %v, want ErrCircularDependency", err)
+	}
+}
+
+func TestCheckCycleAllowsDecorator(t *testing.T) {
+	c := New()
+	c.Bind(&consoleLogger{})
+	// 装饰器依赖的接口由绑定的值满足，不构成循环依赖
+	if err := c.Factory(func(base cycleLogger) *prefixLogger { return &prefixLogger{base} }); err != nil {
+		t.Fatal(err)
+	}
+	val := mustGet(t, c, "", typeOf[*prefixLogger]())
+	if _, ok := val.Interface().(*prefixLogger).base.(*consoleLogger); !ok {
+		t.Fatal("decorator did not receive the bound logger")
+	}
+
+	// 有其它工厂函数实现了接口时同样如此
+	f := New()
+	if err := f.Factory(func() *consoleLogger { return &consoleLogger{} }); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Factory(func(base cycleLogger) *prefixLogger { return &prefixLogger{base} }); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type healthyService struct{}
+type brokenService struct{}
+
+func TestHealthCheck(t *testing.T) {
+	c := New()
+	c.Bind(&consoleLogger{})
+	ran := false
+	if err := c.Factory(func(cycleLogger) *healthyService { ran = true; return &healthyService{} }); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Factory(func(cycleLogger, missingDep) *brokenService { ran = true; return &brokenService{} }); err != nil {
+		t.Fatal(err)
+	}
+	got := c.HealthCheck()
+	if ran {
+		t.Fatal("HealthCheck executed a factory")
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d unsatisfied entries, want 1: %v", len(got), got)
+	}
+	if got[0].Type != typeOf[*brokenService]() || got[0].Dependency != typeOf[missingDep]() {
+		t.Fatalf("got %+v", got[0])
+	}
+}
+
+type defaultDep interface{ Default() }
+
+type defaultDepImpl struct{}
+
+func (defaultDepImpl) Default() {}
+
+func TestHealthCheckUsesDefaults(t *testing.T) {
+	c := New()
+	c.BindDefault((*defaultDep)(nil), defaultDepImpl{})
+	if err := c.Factory(func(defaultDep) *healthyService { return &healthyService{} }); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.HealthCheck(); len(got) != 0 {
+		t.Fatalf("got %v, the default implementation satisfies the dependency", got)
+	}
+}
+
+func TestGraphDOT(t *testing.T) {
+	c := New()
+	c.Bind(&consoleLogger{})
+	if err := c.Factory(func(cycleLogger) *healthyService { return &healthyService{} }, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.NamedFactory("broken", func(*healthyService) *brokenService { return &brokenService{} }); err != nil {
+		t.Fatal(err)
+	}
+	dot := c.GraphDOT()
+	for _, want := range []string{
+		"digraph ioc {",
+		`"*ioc.consoleLogger" [shape=box, label="*ioc.consoleLogger\ninstance"];`,
+		`"*ioc.healthyService" [label="*ioc.healthyService\nshared"];`,
+		`"*ioc.brokenService[broken]" [label="*ioc.brokenService[broken]\ntransient"];`,
+		`"*ioc.healthyService" -> "ioc.cycleLogger";`,
+		`"*ioc.brokenService[broken]" -> "*ioc.healthyService";`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output does not contain %s:\n%s", want, dot)
+		}
+	}
+}
+
+func TestResolvedType(t *testing.T) {
+	c := New()
+	if err := c.BindType(typeOf[greeter](), &chineseGreeter{}); err != nil {
+		t.Fatal(err)
+	}
+	built := false
+	if err := c.Factory(func() cycleLogger { built = true; return &consoleLogger{} }); err != nil {
+		t.Fatal(err)
+	}
+	c.Bind(&englishGreeter{})
+	cases := []struct {
+		typ  reflect.Type
+		want reflect.Type
+	}{
+		{typeOf[greeter](), typeOf[*chineseGreeter]()},
+		{typeOf[cycleLogger](), typeOf[cycleLogger]()},
+		{typeOf[*englishGreeter](), typeOf[*englishGreeter]()},
+		{typeOf[*paramC](), typeOf[*paramC]()},
+	}
+	for _, tc := range cases {
+		got, ok := c.ResolvedType("", tc.typ)
+		if !ok || got != tc.want {
+			t.Errorf("%v: got %v, %v, want %v", tc.typ, got, ok, tc.want)
+		}
+	}
+	if built {
+		t.Error("ResolvedType ran a factory")
+	}
+	if _, ok := c.ResolvedType("", typeOf[missingDep]()); ok {
+		t.Error("want false for an unbound interface")
+	}
+}
+
+func TestResolvedTypeFollowsLookupOrder(t *testing.T) {
+	gt := typeOf[greeter]()
+	check := func(c *Container, name string, typ, want reflect.Type) {
+		t.Helper()
+		got, ok := c.ResolvedType(name, typ)
+		if !ok || got != want {
+			t.Errorf("%v %q: got %v, %v, want %v", typ, name, got, ok, want)
+		}
+		// 与实际获取的结果一致
+		if v := mustGet(t, c, name, typ); concreteType(v) != want {
+			t.Errorf("%v %q: Get returned %v, want %v", typ, name, concreteType(v), want)
+		}
+	}
+
+	c := New(WithDefaultName("primary"))
+	c.Bind(&englishGreeter{})
+	c.NamedBind("primary", &chineseGreeter{})
+	check(c, "", typeOf[*chineseGreeter](), typeOf[*chineseGreeter]())
+	check(c, "", gt, typeOf[*chineseGreeter]())
+
+	c = New(WithNameFallback())
+	c.Bind(&englishGreeter{})
+	check(c, "missing", gt, typeOf[*englishGreeter]())
+
+	// 绑定了值而请求其指针或多级指针
+	c = New()
+	c.Bind(endpoint{URL: "http://a"})
+	check(c, "", typeOf[*endpoint](), typeOf[*endpoint]())
+	check(c, "", typeOf[**endpoint](), typeOf[**endpoint]())
+
+	// 绑定了指针而请求其值
+	c = New(WithPointerAdaptation())
+	c.Bind(&settings{Level: 3})
+	check(c, "", typeOf[settings](), typeOf[settings]())
+
+	// 与上下文匹配的绑定没有上下文可用，不被考虑
+	c = New()
+	c.BindForContext(tenantKey{}, func(v any) bool { return v == "acme" }, &englishGreeter{})
+	if _, ok := c.ResolvedType("", gt); ok {
+		t.Error("context bindings should not be considered without a context")
+	}
+}
@@ -0,0 +1,112 @@
+package ioc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// groupMember 值组中的一个成员，可以是绑定的值，也可以是工厂函数
+type groupMember struct {
+	value   reflect.Value
+	factory *binding
+	spread  bool // 工厂函数返回的是切片，其中的每个元素都是值组的成员
+}
+
+// typ 返回成员的类型，对于工厂函数是其返回值的类型，
+// 对于返回切片的工厂函数是切片元素的类型
+func (m groupMember) typ() reflect.Type {
+	if m.factory != nil {
+		if m.spread {
+			return m.factory.typ.Elem()
+		}
+		return m.factory.typ
+	}
+	return m.value.Type()
+}
+
+// GroupBind 将值加入名称为 group 的值组，同一个值组可以加入任意多个成员，
+// 获取值组时按照加入的顺序返回。与 NamedBind 不同，加入值组的值不会覆盖
+// 之前加入的值，也不能通过 Get 等方法单独获取。
+func (c *Container) GroupBind(group string, value any) {
+	rv := reflect.ValueOf(value)
+	if !rv.IsValid() {
+		return
+	}
+	c.addGroupMember(group, groupMember{value: rv})
+}
+
+// GroupFactory 将工厂函数加入名称为 group 的值组，获取值组时执行工厂函数构建成员，
+// 配置项 opts 与 FactoryWith 相同，如使用 Shared() 时成员只会构建一次。
+func (c *Container) GroupFactory(group string, factory any, opts ...FactoryOption) error {
+	b, err := newBinding(group, factory, opts...)
+	if err != nil {
+		return err
+	}
+	if err = c.checkCycle(b); err != nil {
+		return err
+	}
+	c.addGroupMember(group, groupMember{factory: b})
+	return nil
+}
+
+// GroupFactorySpread 将返回切片（如 []Handler）的工厂函数加入名称为 group 的值组，
+// 获取值组时执行工厂函数，并将切片中的每个元素按顺序作为值组的成员，适用于一个
+// 工厂函数同时提供多个成员的场景，配置项 opts 与 GroupFactory 相同。使用 Shared()
+// 时，容器关闭的是切片中实现了 io.Closer 接口的每个元素。
+func (c *Container) GroupFactorySpread(group string, factory any, opts ...FactoryOption) error {
+	b, err := newBinding(group, factory, opts...)
+	if err != nil {
+		return err
+	}
+	if b.typ.Kind() != reflect.Slice {
+		return fmt.Errorf("ioc: group factory must return a slice, got %v", b.typ)
+	}
+	b.spread = true
+	if err = c.checkCycle(b); err != nil {
+		return err
+	}
+	c.addGroupMember(group, groupMember{factory: b, spread: true})
+	return nil
+}
+
+func (c *Container) addGroupMember(group string, m groupMember) {
+	if c.groups == nil {
+		c.groups = make(map[string][]groupMember)
+	}
+	c.groups[group] = append(c.groups[group], m)
+	c.touch()
+}
+
+// GetGroup 获取名称为 group 的值组中所有能够赋值给类型 t 的成员，先本容器后父容器，
+// 同一容器内按照加入的顺序；值组为空时返回空的切片而不是错误。
+func (c *Container) GetGroup(group string, t reflect.Type) ([]reflect.Value, error) {
+	return c.getGroup(context.Background(), group, t)
+}
+
+func (c *Container) getGroup(ctx context.Context, group string, t reflect.Type) ([]reflect.Value, error) {
+	var values []reflect.Value
+	for _, ci := range c.lineage() {
+		for _, m := range ci.groups[group] {
+			if !m.typ().AssignableTo(t) {
+				continue
+			}
+			if m.factory == nil {
+				values = append(values, m.value)
+				continue
+			}
+			val, err := m.factory.make(ctx, ci)
+			if err != nil {
+				return nil, fmt.Errorf("ioc: cannot build member of group %q: %w", group, err)
+			}
+			if m.spread {
+				for i := 0; i < val.Len(); i++ {
+					values = append(values, val.Index(i))
+				}
+				continue
+			}
+			values = append(values, val)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,85 @@
+package ioc
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	t.Cleanup(Snapshot())
+	GroupBind("greeters", &englishGreeter{})
+	GroupBind("greeters", &chineseGreeter{})
+	if err := GroupFactory("greeters", func() greeter { return frenchGreeter{} }); err != nil {
+		t.Fatal(err)
+	}
+	GroupBind("others", &englishGreeter{})
+	got, err := Group[greeter](context.Background(), "greeters")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"hello", "你好", "bonjour"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d members, want %d", len(got), len(want))
+	}
+	for i, g := range got {
+		if g.Greet() != want[i] {
+			t.Fatalf("member %d: got %q, want %q", i, g.Greet(), want[i])
+		}
+	}
+}
+
+func TestGroupFactorySpread(t *testing.T) {
+	c := New()
+	c.GroupBind("greeters", &englishGreeter{})
+	calls := 0
+	err := c.GroupFactorySpread("greeters", func() []greeter {
+		calls++
+		return []greeter{&chineseGreeter{}, frenchGreeter{}, &englishGreeter{}}
+	}, Shared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		got, err := Group[greeter](c.NewContext(), "greeters")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"hello", "你好", "bonjour", "hello"}
+		if len(got) != len(want) {
+			t.Fatalf("got %d members, want %d", len(got), len(want))
+		}
+		for i, g := range got {
+			if g.Greet() != want[i] {
+				t.Fatalf("member %d: got %q, want %q", i, g.Greet(), want[i])
+			}
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("shared factory ran %d times", calls)
+	}
+	if err := c.GroupFactorySpread("greeters", func() greeter { return frenchGreeter{} }); err == nil {
+		t.Fatal("want an error for a factory that does not return a slice")
+	}
+}
+
+func TestGroupFactorySpreadClosesElements(t *testing.T) {
+	var log []string
+	c := New()
+	err := c.GroupFactorySpread("closers", func() []*trackedCloser {
+		return []*trackedCloser{{"a", &log}, {"b", &log}}
+	}, Shared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetGroup("closers", typeOf[*trackedCloser]()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// 共享的切片中的每个元素都会被关闭
+	if len(log) != 2 || !slices.Contains(log, "a") || !slices.Contains(log, "b") {
+		t.Fatalf("got %v, want every element closed", log)
+	}
+}
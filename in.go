@@ -0,0 +1,53 @@
+package ioc
+
+import "reflect"
+
+// In 参数对象的标记，嵌入了 In 的结构体称为参数对象，通常作为工厂函数或者
+// Invoke 执行的函数的参数，用来声明一组依赖，其字段按照与 Resolve 相同的规则
+// （包括 ioc 标签）逐个注入：
+//
+//	type Params struct {
+//		ioc.In
+//		DB    *sql.DB
+//		Cache Cache `ioc:"redis"`
+//	}
+//
+// 使用 omitempty（或者 optional）标记的字段是可选的依赖，找不到时保持零值，
+// 而不会导致整个参数对象构建失败：
+//
+//	Logger Logger `ioc:",optional"`
+//
+// 参数对象可以嵌入其它的参数对象，被嵌入的参数对象会被展开，其字段与外层的
+// 字段一样被注入。
+type In struct{}
+
+var inType = reflect.TypeOf(In{})
+
+// isInStruct 判断类型是否为嵌入了 In 的参数对象
+func isInStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.Anonymous && f.Type == inType {
+			return true
+		}
+	}
+	return false
+}
+
+// inFields 返回参数对象中需要注入的字段，嵌入的参数对象会被展开
+func inFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		switch {
+		case !field.IsExported() || field.Anonymous && field.Type == inType:
+		case field.Anonymous && isInStruct(field.Type):
+			fields = append(fields, inFields(field.Type)...)
+		default:
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,68 @@
+package ioc
+
+import "testing"
+
+type BaseParams struct {
+	In
+	A *paramA
+}
+
+type nestedParams struct {
+	In
+	BaseParams
+	C *paramC
+}
+
+func TestNestedInStruct(t *testing.T) {
+	c := New()
+	a, cc := &paramA{}, &paramC{}
+	c.Bind(a)
+	c.Bind(cc)
+	var got nestedParams
+	if _, err := c.Invoke(func(p nestedParams) { got = p }); err != nil {
+		t.Fatal(err)
+	}
+	if got.A != a || got.C != cc {
+		t.Fatalf("got %+v, want all leaf fields injected", got)
+	}
+}
+
+type optionalParams struct {
+	In
+	A *paramA
+	M missingDep `ioc:",optional"`
+}
+
+func TestOptionalInField(t *testing.T) {
+	c := New()
+	c.Bind(&paramA{})
+	var got optionalParams
+	if _, err := c.Invoke(func(p optionalParams) { got = p }); err != nil {
+		t.Fatal(err)
+	}
+	if got.A == nil || got.M != nil {
+		t.Fatalf("got %+v, want A injected and M left zero", got)
+	}
+}
+
+type cache struct{ name string }
+
+type namedParams struct {
+	In
+	DB    *database `ioc:"replica"`
+	Cache *cache
+}
+
+func TestNamedInField(t *testing.T) {
+	c := New()
+	c.Bind(&database{addr: "primary"})
+	c.NamedBind("replica", &database{addr: "replica"})
+	c.Bind(&cache{name: "default"})
+	var got namedParams
+	if _, err := c.Invoke(func(p namedParams) { got = p }); err != nil {
+		t.Fatal(err)
+	}
+	if got.DB.addr != "replica" || got.Cache.name != "default" {
+		t.Fatalf("got db %q and cache %q", got.DB.addr, got.Cache.name)
+	}
+}
@@ -0,0 +1,78 @@
+package ioc
+
+import (
+	"reflect"
+	"slices"
+)
+
+// IndexInterfaces 为接口预先计算其“具体实现”所在的绑定（包括绑定的值与工厂函数），
+// 之后获取这些接口时直接使用索引，而不需要遍历本容器中所有的绑定。参数为接口的指针，如：
+//
+//	c.IndexInterfaces((*Logger)(nil), (*Cache)(nil))
+//
+// 索引只针对本容器自身的绑定，绑定发生变化之后会在下次使用时自动重建，
+// 解析的结果与未建立索引时一致。
+func (c *Container) IndexInterfaces(ifacePtrs ...any) {
+	for _, ptr := range ifacePtrs {
+		if t := InterfaceOf(ptr); !slices.Contains(c.indexed, t) {
+			c.indexed = append(c.indexed, t)
+		}
+	}
+	c.rebuildIndex()
+}
+
+// interfaceIndex 一个接口在本容器中的索引，按名称记录实现了该接口的类型，
+// 同一名称下可能有多个类型实现了接口，它们按照类型排序。
+type interfaceIndex struct {
+	instances map[string][]reflect.Type // 绑定的值
+	factories map[string][]reflect.Type // 工厂函数
+}
+
+// rebuildIndex 重新计算所有需要索引的接口在本容器中对应的绑定
+func (c *Container) rebuildIndex() {
+	c.index = make(map[reflect.Type]interfaceIndex, len(c.indexed))
+	for _, t := range c.indexed {
+		idx := interfaceIndex{
+			instances: make(map[string][]reflect.Type),
+			factories: make(map[string][]reflect.Type),
+		}
+		for _, rt := range sortedAssignable(c.instances, t) {
+			for name := range c.instances[rt] {
+				idx.instances[name] = append(idx.instances[name], rt)
+			}
+		}
+		for _, rt := range sortedAssignable(c.factories, t) {
+			// 索引的都是接口，WithInterfaceOnly 的工厂函数同样可以获取
+			for name := range c.factories[rt] {
+				idx.factories[name] = append(idx.factories[name], rt)
+			}
+		}
+		c.index[t] = idx
+	}
+	c.indexGen = c.gen
+}
+
+// sortedAssignable 返回 m 中除 t 本身以外能够赋值给 t 的类型，按照类型排序
+func sortedAssignable[V any](m map[reflect.Type]V, t reflect.Type) []reflect.Type {
+	types := make([]reflect.Type, 0, len(m))
+	for rt := range m {
+		if rt != t && rt.AssignableTo(t) {
+			types = append(types, rt)
+		}
+	}
+	sortTypes(types)
+	return types
+}
+
+// indexedTypes 通过索引查找本容器中以名称 name 绑定且实现了接口 t 的值与工厂函数的类型，
+// 返回值 indexed 表示接口 t 是否建立了索引，没有时需要遍历所有的绑定。
+func (c *Container) indexedTypes(name string, t reflect.Type) (instances, factories []reflect.Type, indexed bool) {
+	if !slices.Contains(c.indexed, t) {
+		return nil, nil, false
+	}
+	if c.indexGen != c.gen {
+		c.rebuildIndex()
+	}
+	idx := c.index[t]
+	return slices.Clone(idx.instances[name]), slices.Clone(idx.factories[name]), true
+}
@@ -0,0 +1,87 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// indexFixture 在容器中绑定实现了 greeter 与 cycleLogger 的值与工厂函数
+func indexFixture(c *Container) {
+	c.Bind(&englishGreeter{})
+	c.NamedBind("zh", &chineseGreeter{})
+	c.NamedBind("ambiguous", &englishGreeter{})
+	c.NamedBind("ambiguous", &chineseGreeter{})
+	_ = c.Factory(func() *consoleLogger { return &consoleLogger{} }, true)
+	_ = c.NamedFactory("fr", func() frenchGreeter { return frenchGreeter{} })
+	_ = c.NamedFactoryWith("iface", func() *prefixLogger { return &prefixLogger{} }, WithInterfaceOnly())
+}
+
+func TestIndexInterfaces(t *testing.T) {
+	plain, indexed := New(), New()
+	indexFixture(plain)
+	indexFixture(indexed)
+	indexed.IndexInterfaces((*greeter)(nil), (*cycleLogger)(nil))
+
+	compare := func(step string) {
+		t.Helper()
+		for _, typ := range []reflect.Type{typeOf[greeter](), typeOf[cycleLogger]()} {
+			for _, name := range []string{"", "zh", "fr", "ambiguous", "iface", "missing"} {
+				want, wantErr := plain.NamedGet(name, typ)
+				got, gotErr := indexed.NamedGet(name, typ)
+				if fmt.Sprint(gotErr) != fmt.Sprint(wantErr) {
+					t.Errorf("%s %v %q: got error %v, want %v", step, typ, name, gotErr, wantErr)
+					continue
+				}
+				if want.IsValid() != got.IsValid() || want.IsValid() && reflect.TypeOf(want.Interface()) != reflect.TypeOf(got.Interface()) {
+					t.Errorf("%s %v %q: got %v, want %v", step, typ, name, got, want)
+				}
+			}
+		}
+	}
+	compare("initial")
+	if len(indexed.index[typeOf[greeter]()].factories["fr"]) != 1 {
+		t.Fatal("the factory was not indexed")
+	}
+
+	// 绑定发生变化后索引自动重建
+	for _, c := range []*Container{plain, indexed} {
+		c.NamedBind("fr", &englishGreeter{})
+		c.Unbind("zh", typeOf[*chineseGreeter]())
+		_ = c.NamedFactory("zh", func() *chineseGreeter { return &chineseGreeter{} })
+	}
+	compare("rebound")
+}
+
+func BenchmarkIndexInterfaces(b *testing.B) {
+	setup := func() *Container {
+		c := New()
+		// 大量无关的绑定，未建立索引时每次获取接口都需要遍历它们
+		for i := 1; i <= 200; i++ {
+			at := reflect.ArrayOf(i, typeOf[int]())
+			_ = c.BindType(at, reflect.New(at).Elem().Interface())
+			ft := reflect.FuncOf(nil, []reflect.Type{reflect.ArrayOf(i, typeOf[string]())}, false)
+			_ = c.Factory(reflect.MakeFunc(ft, func([]reflect.Value) []reflect.Value {
+				return []reflect.Value{reflect.New(ft.Out(0)).Elem()}
+			}).Interface())
+		}
+		_ = c.Factory(func() *englishGreeter { return &englishGreeter{} }, true)
+		return c
+	}
+	typ := typeOf[greeter]()
+	run := func(b *testing.B, c *Container) {
+		for i := 0; i < b.N; i++ {
+			if _, err := c.Get(typ); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.Run("unindexed", func(b *testing.B) {
+		run(b, setup())
+	})
+	b.Run("indexed", func(b *testing.B) {
+		c := setup()
+		c.IndexInterfaces((*greeter)(nil))
+		run(b, c)
+	})
+}
@@ -2,15 +2,16 @@ package ioc
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"reflect"
+	"time"
 )
 
 var global = New()
 
 // Fork 分支
-func Fork() *Container {
-	return global.Fork()
+func Fork(opts ...Option) *Container {
+	return global.Fork(opts...)
 }
 
 // Bind 绑定值到容器，有效类型：
@@ -22,9 +23,110 @@ func Bind(instance any) {
 	global.Bind(instance)
 }
 
-// NamedBind 绑定具名值到容器
-func NamedBind(name string, instance any) {
-	global.NamedBind(name, instance)
+// MustBind 绑定值到容器，值以类型参数 T 为键绑定（与 MustNamedBind 相同），
+// 值为 nil 或者在严格模式下重复绑定时触发 panic
+func MustBind[T any](instance T) {
+	MustNamedBind("", instance)
+}
+
+// MustNamedBind 绑定具名值到容器，值以类型参数 T 为键绑定（与 NamedBind 相同），
+// 值为 nil 或者在严格模式下重复绑定时触发 panic
+func MustNamedBind[T any](name string, instance T) {
+	t := typeOf[T]()
+	mustNotNil(t, instance)
+	if t.Kind() == reflect.Interface && t.NumMethod() == 0 {
+		global.MustNamedBind(name, instance)
+		return
+	}
+	global.mustNotBound(name, t)
+	NamedBind(name, instance)
+}
+
+// BindWithCleanup 绑定值到容器，并在容器关闭时执行清理函数
+func BindWithCleanup(instance any, cleanup func()) {
+	global.BindWithCleanup(instance, cleanup)
+}
+
+// BindWithCleanupPriority 绑定值到容器，并在容器关闭时按照优先级执行清理函数
+func BindWithCleanupPriority(instance any, cleanup func(), priority int) {
+	global.BindWithCleanupPriority(instance, cleanup, priority)
+}
+
+// NamedBind 绑定具名值到容器，值以类型参数 T 为键绑定，因此可以在编译期
+// 检查地将值绑定为接口，如 ioc.NamedBind[Cache]("redis", redisCache)；
+// T 为 any 等空接口时，与 Container.NamedBind 一样使用值本身的类型。
+//
+// 注意：T 省略时由参数的静态类型推断，因此传入接口类型的变量时，值以该接口
+// 为键绑定，而在 NamedBind 泛型化之前以值本身的类型为键，如：
+//
+//	var g Greeter = &English{}
+//	ioc.NamedBind("en", g)      // 以 Greeter 为键，之前以 *English 为键
+//	ioc.NamedBind[any]("en", g) // 以 *English 为键，与之前一致
+//
+// 此时直接获取 *English 将找不到该值，需要保持原有行为时应显式指定 T 为 any。
+func NamedBind[T any](name string, instance T) {
+	t := typeOf[T]()
+	if t.Kind() == reflect.Interface && t.NumMethod() == 0 {
+		global.NamedBind(name, instance)
+		return
+	}
+	// 只有值为 nil 接口时才会失败，与绑定无类型的 nil 一样被忽略
+	_ = global.NamedBindType(name, t, instance)
+}
+
+// Provide 批量绑定工厂函数
+func Provide(factories ...any) error {
+	return global.Provide(factories...)
+}
+
+// BindMany 批量绑定具名值到容器
+func BindMany(values map[string]any) {
+	global.BindMany(values)
+}
+
+// ProvideNamed 批量绑定具名工厂函数
+func ProvideNamed(entries map[string]any) error {
+	return global.ProvideNamed(entries)
+}
+
+// BindBothNames 同时以名称与匿名的方式绑定值到容器
+func BindBothNames(name string, instance any) {
+	global.BindBothNames(name, instance)
+}
+
+// BindWithInterfaces 绑定值到容器，同时绑定到 ifacePtrs 中的每个接口上
+func BindWithInterfaces(instance any, ifacePtrs ...any) {
+	global.BindWithInterfaces(instance, ifacePtrs...)
+}
+
+// BindType 绑定值到容器中指定的类型上
+func BindType(t reflect.Type, instance any) error {
+	return global.BindType(t, instance)
+}
+
+// NamedBindType 绑定具名值到容器中指定的类型上
+func NamedBindType(name string, t reflect.Type, instance any) error {
+	return global.NamedBindType(name, t, instance)
+}
+
+// LoadEnv 将环境变量绑定到容器
+func LoadEnv(prefix string) {
+	global.LoadEnv(prefix)
+}
+
+// Alias 为类型设置别名
+func Alias(alias, target reflect.Type) error {
+	return global.Alias(alias, target)
+}
+
+// BindForContext 绑定根据上下文选择的值到容器
+func BindForContext(key any, match func(v any) bool, value any) {
+	global.BindForContext(key, match, value)
+}
+
+// BindDefault 为接口绑定默认实现
+func BindDefault(ifacePtr any, value any) {
+	global.BindDefault(ifacePtr, value)
 }
 
 // Factory 绑定工厂函数
@@ -51,15 +153,71 @@ func MustNamedFactory(name string, factory any, shared ...bool) {
 	}
 }
 
+// FactoryWith 使用配置项绑定工厂函数
+func FactoryWith(factory any, opts ...FactoryOption) error {
+	return global.FactoryWith(factory, opts...)
+}
+
+// NamedFactoryWith 使用配置项绑定具名工厂函数
+func NamedFactoryWith(name string, factory any, opts ...FactoryOption) error {
+	return global.NamedFactoryWith(name, factory, opts...)
+}
+
+// BindSingleton 绑定一个没有依赖的共享工厂函数，fn 最多只会执行一次，
+// 绑定失败时触发 panic。
+func BindSingleton[T any](fn func() T) {
+	if err := global.BindSingleton(fn); err != nil {
+		panic(err)
+	}
+}
+
+// BindLazy 绑定一个延迟求值的值，fn 在首次获取（或注入）类型 T 时才执行，
+// 其结果被缓存，之后的获取都返回同一个值；与 BindSingleton 相同，只是更
+// 强调“延迟构建一个值”的意图。绑定失败时触发 panic。
+func BindLazy[T any](fn func() T) {
+	BindSingleton(fn)
+}
+
+// BindTransient 绑定一个没有依赖的非共享工厂函数，每次获取或注入时都会
+// 执行 fn 构建新的实例，绑定失败时触发 panic。
+func BindTransient[T any](fn func() T) {
+	if err := global.BindTransient(fn); err != nil {
+		panic(err)
+	}
+}
+
 // Resolve 完成的注入
 func Resolve(i any) error {
 	return global.Resolve(i)
 }
 
+// ResolveContext 使用上下文完成注入
+func ResolveContext(ctx context.Context, i any) error {
+	return Instance(ctx).ResolveContext(ctx, i)
+}
+
+// ResolveInto 只注入零值字段
+func ResolveInto(i any) error {
+	return global.ResolveInto(i)
+}
+
+// ResolveValue 完成按值传递的结构体的注入，返回注入后的副本
+func ResolveValue(v any) (any, error) {
+	return global.ResolveValue(v)
+}
+
+// ResolveAll 依次完成多个目标的注入
+func ResolveAll(targets ...any) error {
+	return global.ResolveAll(targets...)
+}
+
 // Get 获取指定类型的值，泛型 T 只能是结构体
 //
 // 如果需要获取一个接口的实例，我们可以使用 Instance 函数
 // 返回一个 Container 实例，然后通过改容器来获取接口的具体实现。
+//
+// 泛型类型的不同实例化（如 Repo[User] 与 Repo[Order]）是不同的类型，
+// 它们的绑定与获取互不影响。
 func Get[T any](ctx context.Context) (*T, error) {
 	return NamedGet[T](ctx, "")
 }
@@ -72,23 +230,51 @@ func MustGet[T any](ctx context.Context) *T {
 	return MustNamedGet[T](ctx, "")
 }
 
-// NamedGet 通过注入的名称获取指定类型的值
+// Must 获取类型 T 的值，获取失败时触发 panic。与 MustGet 不同，T 就是要获取的
+// 类型本身，因此可以统一地获取接口（如 Must[Logger]）、结构体指针（如 Must[*Service]，
+// 未绑定时自动构建）以及结构体的值。使用上下文中的服务容器，没有时使用全局服务容器。
+//
+// T 为结构体时与 Get 一样获取 *T（绑定的 *T 或 T，未绑定时自动构建），
+// 然后返回其指向的值的副本。
+func Must[T any](ctx context.Context) T {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	t := typeOf[T]()
+	isStruct := t.Kind() == reflect.Struct
+	if isStruct {
+		t = reflect.PointerTo(t)
+	}
+	val, err := Instance(ctx).GetContext(ctx, t)
+	if err != nil {
+		panic(err)
+	}
+	if isStruct && val.IsValid() {
+		val = val.Elem()
+	}
+	return valueAs[T](val)
+}
+
+// NamedGet 通过注入的名称获取指定类型的值，使用上下文中的服务容器（包括其
+// 父容器链），只有上下文中没有服务容器时才使用全局服务容器。
 func NamedGet[T any](ctx context.Context, name string) (*T, error) {
 	var abstract T
-	t := reflect.TypeOf(&abstract)
-	if ctx != nil {
-		if ci, ok := ctx.Value(contextKey).(*Container); ok {
-			val, err := ci.NamedGet(name, t)
-			if err != nil {
-				if !errors.Is(err, ErrValueNotFound) {
-					return nil, err
-				}
-			} else if val.IsValid() {
-				return val.Interface().(*T), nil
-			}
-		}
+	val, err := Instance(ctx).NamedGetContext(ctx, name, reflect.TypeOf(&abstract))
+	if err != nil {
+		return nil, err
 	}
-	val, err := global.NamedGet(name, t)
+	if !val.IsValid() {
+		return nil, ErrValueNotFound
+	}
+	return val.Interface().(*T), nil
+}
+
+// GetWithTimeout 在限定的时间内通过注入的名称获取指定类型的值，
+// 超时之后工厂函数可能依然会在后台继续执行，在其完成之前不能再使用
+// 该服务容器，详见 Container.GetWithTimeout。
+func GetWithTimeout[T any](ctx context.Context, name string, d time.Duration) (*T, error) {
+	var abstract T
+	val, err := Instance(ctx).GetWithTimeout(ctx, name, reflect.TypeOf(&abstract), d)
 	if err != nil {
 		return nil, err
 	}
@@ -98,6 +284,52 @@ func NamedGet[T any](ctx context.Context, name string) (*T, error) {
 	return val.Interface().(*T), nil
 }
 
+// GroupBind 将值加入全局容器中名称为 group 的值组
+func GroupBind(group string, value any) {
+	global.GroupBind(group, value)
+}
+
+// GroupFactory 将工厂函数加入全局容器中名称为 group 的值组
+func GroupFactory(group string, factory any, opts ...FactoryOption) error {
+	return global.GroupFactory(group, factory, opts...)
+}
+
+// GroupFactorySpread 将返回切片的工厂函数加入全局容器中名称为 group 的值组，
+// 切片中的每个元素都是值组的成员
+func GroupFactorySpread(group string, factory any, opts ...FactoryOption) error {
+	return global.GroupFactorySpread(group, factory, opts...)
+}
+
+// Group 获取名称为 group 的值组中所有类型为 T 的成员，使用上下文中的服务容器，
+// 没有时使用全局服务容器。
+func Group[T any](ctx context.Context, group string) ([]T, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	values, err := Instance(ctx).getGroup(ctx, group, typeOf[T]())
+	if err != nil {
+		return nil, err
+	}
+	result := make([]T, len(values))
+	for i, v := range values {
+		result[i] = valueAs[T](v)
+	}
+	return result, nil
+}
+
+// GetE 通过运行时的类型与名称获取值，适用于无法在编译期确定类型的场景，
+// 使用上下文中的服务容器，没有时使用全局服务容器。
+func GetE(ctx context.Context, name string, t reflect.Type) (any, error) {
+	val, err := Instance(ctx).NamedGetContext(ctx, name, t)
+	if err != nil {
+		return nil, err
+	}
+	if !val.IsValid() {
+		return nil, ErrValueNotFound
+	}
+	return val.Interface(), nil
+}
+
 func MustNamedGet[T any](ctx context.Context, name string) *T {
 	v, err := NamedGet[T](ctx, name)
 	if err != nil {
@@ -111,6 +343,97 @@ func Invoke(f any) ([]reflect.Value, error) {
 	return global.Invoke(f)
 }
 
+// InvokeContext 使用上下文执行函数，类型为 context.Context 的参数使用 ctx，
+// 使用上下文中的服务容器，没有时使用全局服务容器。
+func InvokeContext(ctx context.Context, f any) ([]reflect.Value, error) {
+	return Instance(ctx).InvokeContext(ctx, f)
+}
+
+// InvokeWith 执行函数，部分参数由调用方提供
+func InvokeWith(f any, provided ...any) ([]reflect.Value, error) {
+	return global.InvokeWith(f, provided...)
+}
+
+// InvokeCleanup 执行函数，并将其所有 func() 类型的返回值组合为一个清理函数
+func InvokeCleanup(f any) (cleanup func(), results []reflect.Value, err error) {
+	return global.InvokeCleanup(f)
+}
+
+// InvokeScan 执行函数，并将其返回值依次赋值给 out 中对应的指针
+func InvokeScan(f any, out ...any) error {
+	return global.InvokeScan(f, out...)
+}
+
+// Invoke1 执行函数并返回其类型化的返回值，函数必须返回一个值，且可以额外返回
+// 一个错误；使用上下文中的服务容器，没有时使用全局服务容器。
+func Invoke1[A any](ctx context.Context, f any) (a A, err error) {
+	out, err := invokeTyped(ctx, f, typeOf[A]())
+	if err != nil {
+		return
+	}
+	return valueAs[A](out[0]), nil
+}
+
+// Invoke2 执行函数并返回其类型化的两个返回值，函数可以额外返回一个错误。
+func Invoke2[A, B any](ctx context.Context, f any) (a A, b B, err error) {
+	out, err := invokeTyped(ctx, f, typeOf[A](), typeOf[B]())
+	if err != nil {
+		return
+	}
+	return valueAs[A](out[0]), valueAs[B](out[1]), nil
+}
+
+// Invoke3 执行函数并返回其类型化的三个返回值，函数可以额外返回一个错误。
+func Invoke3[A, B, C any](ctx context.Context, f any) (a A, b B, c C, err error) {
+	out, err := invokeTyped(ctx, f, typeOf[A](), typeOf[B](), typeOf[C]())
+	if err != nil {
+		return
+	}
+	return valueAs[A](out[0]), valueAs[B](out[1]), valueAs[C](out[2]), nil
+}
+
+// invokeTyped 执行函数，检查其返回值（不包括末尾的错误）与给定的类型一一对应，
+// 若函数末尾返回了非 nil 的错误，则返回该错误。
+func invokeTyped(ctx context.Context, f any, types ...reflect.Type) ([]reflect.Value, error) {
+	rt := reflect.TypeOf(f)
+	if err := checkFunc(rt); err != nil {
+		return nil, err
+	}
+	n := rt.NumOut()
+	hasErr := n > 0 && rt.Out(n-1) == errorType
+	if hasErr {
+		n--
+	}
+	if n != len(types) {
+		return nil, fmt.Errorf("ioc: %v returns %d values, expected %d", rt, n, len(types))
+	}
+	for i, t := range types {
+		if !rt.Out(i).AssignableTo(t) {
+			return nil, fmt.Errorf("ioc: return value %d (%v) of %v is not assignable to %v", i+1, rt.Out(i), rt, t)
+		}
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ci := Instance(ctx)
+	out, err := ci.invoke(ctx, rt, reflect.ValueOf(f), ci.invokeOptions())
+	if err != nil {
+		return nil, err
+	}
+	if hasErr && !out[n].IsNil() {
+		return nil, out[n].Interface().(error)
+	}
+	return out, nil
+}
+
+// Configure 修改全局服务容器的配置项，如 ioc.Configure(ioc.WithStrict(true))，
+// 通常在程序初始化时调用。
+func Configure(opts ...Option) {
+	for _, opt := range opts {
+		opt(&global.opts)
+	}
+}
+
 func NewContext(parentCtx ...context.Context) context.Context {
 	return global.NewContext(parentCtx...)
 }
@@ -124,3 +447,14 @@ func Instance(ctx context.Context) *Container {
 	}
 	return global
 }
+
+// MustInstance 返回上下文中的服务容器，与 Instance 不同，没有时不会使用
+// 全局服务容器，而是直接 panic，适用于必须使用请求级别服务容器的场景。
+func MustInstance(ctx context.Context) *Container {
+	if ctx != nil {
+		if ci, ok := ctx.Value(contextKey).(*Container); ok {
+			return ci
+		}
+	}
+	panic("ioc: no container attached to the context")
+}
@@ -0,0 +1,362 @@
+package ioc
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetE(t *testing.T) {
+	t.Cleanup(Snapshot())
+	if err := BindType(reflect.TypeOf((*greeter)(nil)).Elem(), &englishGreeter{}); err != nil {
+		t.Fatal(err)
+	}
+	// 类型在运行时才确定
+	typ := reflect.TypeOf(struct{ G greeter }{}).Field(0).Type
+	v, err := GetE(context.Background(), "", typ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.(greeter).Greet() != "hello" {
+		t.Fatalf("got %v", v)
+	}
+	if _, err := GetE(context.Background(), "missing", typ); err == nil {
+		t.Fatal("expected an error for a missing name")
+	}
+}
+
+func TestMustBind(t *testing.T) {
+	t.Cleanup(Snapshot())
+	mustPanic := func(typ string, fn func()) {
+		t.Helper()
+		defer func() {
+			r := recover()
+			if r == nil {
+				t.Fatalf("%s: expected a panic", typ)
+			}
+			if msg, _ := r.(string); !strings.Contains(msg, "cannot bind nil value of type "+typ) {
+				t.Fatalf("%s: unexpected panic %v", typ, r)
+			}
+		}()
+		fn()
+	}
+	mustPanic("*ioc.namedLogger", func() { MustBind[*namedLogger](nil) })
+	mustPanic("ioc.greeter", func() { MustBind[greeter](nil) })
+	mustPanic("func()", func() { MustBind[func()](nil) })
+	mustPanic("map[string]int", func() { MustBind[map[string]int](nil) })
+	mustPanic("[]int", func() { New().MustBind([]int(nil)) })
+
+	MustBind(&namedLogger{prefix: "must"})
+	v, err := global.Get(reflect.TypeOf(&namedLogger{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := v.Interface().(*namedLogger).prefix; got != "must" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestInvoke2And3(t *testing.T) {
+	c := New()
+	c.Bind(&namedLogger{prefix: "x"})
+	ctx := c.NewContext()
+
+	a, b, err := Invoke2[string, int](ctx, func(l *namedLogger) (string, int) { return l.prefix, 2 })
+	if err != nil || a != "x" || b != 2 {
+		t.Fatalf("got %q, %d, %v", a, b, err)
+	}
+	errFailed := errors.New("failed")
+	if _, _, err := Invoke2[string, int](ctx, func() (string, int, error) { return "", 0, errFailed }); !errors.Is(err, errFailed) {
+		t.Fatalf("got %v, want the trailing error", err)
+	}
+
+	x, y, z, err := Invoke3[string, int, bool](ctx, func(l *namedLogger) (string, int, bool, error) { return l.prefix, 3, true, nil })
+	if err != nil || x != "x" || y != 3 || !z {
+		t.Fatalf("got %q, %d, %v, %v", x, y, z, err)
+	}
+	if _, _, _, err := Invoke3[string, int, bool](ctx, func() (string, int, bool) { return "", 0, false }); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := Invoke3[string, int, bool](ctx, func() (string, int) { return "", 0 }); err == nil {
+		t.Fatal("expected an error for a mismatched number of results")
+	}
+}
+
+type repoUser struct{ Name string }
+type repoOrder struct{ ID int }
+
+type repo[T any] struct{ table string }
+
+func TestGenericInstantiationsAreDistinct(t *testing.T) {
+	c := New()
+	c.Bind(&repo[repoUser]{table: "users"})
+	c.Bind(&repo[repoOrder]{table: "orders"})
+	ctx := c.NewContext()
+
+	users, err := Get[*repo[repoUser]](ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders, err := Get[*repo[repoOrder]](ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (*users).table != "users" || (*orders).table != "orders" {
+		t.Fatalf("got %q and %q", (*users).table, (*orders).table)
+	}
+
+	var s struct {
+		Users  *repo[repoUser]
+		Orders *repo[repoOrder]
+	}
+	if err := c.Resolve(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Users.table != "users" || s.Orders.table != "orders" {
+		t.Fatalf("got %q and %q", s.Users.table, s.Orders.table)
+	}
+}
+
+func TestNamedGetSurfacesContextContainerErrors(t *testing.T) {
+	t.Cleanup(Snapshot())
+	Bind(&namedLogger{prefix: "global"})
+	errFailed := errors.New("failed")
+	c := New()
+	if err := c.Factory(func() (*namedLogger, error) { return nil, errFailed }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Get[*namedLogger](c.NewContext()); !errors.Is(err, errFailed) {
+		t.Fatalf("got %v, want the error of the context container's factory", err)
+	}
+	// 上下文中没有服务容器时使用全局服务容器
+	v, err := Get[*namedLogger](context.Background())
+	if err != nil || (*v).prefix != "global" {
+		t.Fatalf("got %v, %v", v, err)
+	}
+}
+
+func TestMustInstance(t *testing.T) {
+	c := New()
+	if got := MustInstance(c.NewContext()); got != c {
+		t.Fatal("MustInstance did not return the attached container")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for a context without a container")
+		}
+	}()
+	MustInstance(context.Background())
+}
+
+func TestBindSingleton(t *testing.T) {
+	t.Cleanup(Snapshot())
+	calls := 0
+	BindSingleton(func() *sharedService {
+		calls++
+		return &sharedService{n: calls}
+	})
+	first := MustGet[*sharedService](context.Background())
+	for i := 0; i < 3; i++ {
+		if got := MustGet[*sharedService](context.Background()); *got != *first {
+			t.Fatalf("got a different instance on call %d", i)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("fn ran %d times, want 1", calls)
+	}
+}
+
+func TestBindTransient(t *testing.T) {
+	t.Cleanup(Snapshot())
+	BindTransient(func() *sharedService { return &sharedService{} })
+	a := MustGet[*sharedService](context.Background())
+	b := MustGet[*sharedService](context.Background())
+	if *a == *b {
+		t.Fatal("got the same instance twice, want a fresh one per Get")
+	}
+}
+
+func TestNamedGetRuntimeNames(t *testing.T) {
+	c := New()
+	names := []string{"", "主库", "  spaced  ", "a/b:c", "emoji-🚀", "\t"}
+	for _, name := range names {
+		c.NamedBind(name, &namedLogger{prefix: name})
+	}
+	ctx := c.NewContext()
+	for _, name := range names {
+		nameFn := func() string { return name }
+		v, err := NamedGet[namedLogger](ctx, nameFn())
+		if err != nil {
+			t.Fatalf("%q: %v", name, err)
+		}
+		if v.prefix != name {
+			t.Fatalf("%q: got %q", name, v.prefix)
+		}
+	}
+}
+
+func TestGenericNamedBind(t *testing.T) {
+	t.Cleanup(Snapshot())
+	NamedBind[greeter]("en", &englishGreeter{})
+	NamedBind[greeter]("zh", &chineseGreeter{})
+	for name, want := range map[string]string{"en": "hello", "zh": "你好"} {
+		g, err := NamedGet[greeter](context.Background(), name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if (*g).Greet() != want {
+			t.Fatalf("%s: got %q, want %q", name, (*g).Greet(), want)
+		}
+	}
+	// 值以接口类型为键绑定，而不是其实际类型
+	if _, ok := global.instances[typeOf[greeter]()]["en"]; !ok {
+		t.Fatal("the value was not bound under the interface type")
+	}
+}
+
+func TestGenericNamedBindInferredKey(t *testing.T) {
+	t.Cleanup(Snapshot())
+	var g greeter = &englishGreeter{}
+	// 由接口类型的变量推断出 T 时以接口为键，泛型化之前以值本身的类型为键
+	NamedBind("inferred", g)
+	if _, ok := global.instances[typeOf[greeter]()]["inferred"]; !ok {
+		t.Fatal("the value was not bound under the inferred interface type")
+	}
+	if _, ok := global.instances[typeOf[*englishGreeter]()]["inferred"]; ok {
+		t.Fatal("the value was bound under its dynamic type")
+	}
+	// 指定 T 为 any 时保持原有的行为
+	NamedBind[any]("dynamic", g)
+	if _, ok := global.instances[typeOf[*englishGreeter]()]["dynamic"]; !ok {
+		t.Fatal("the value was not bound under its dynamic type")
+	}
+	if _, ok := global.instances[typeOf[greeter]()]["dynamic"]; ok {
+		t.Fatal("the value was bound under the interface type")
+	}
+}
+
+func TestNamedGetUsesContextParentChain(t *testing.T) {
+	t.Cleanup(Snapshot())
+	root := New()
+	root.NamedBind("audit", &namedLogger{prefix: "root"})
+	root.Bind(&endpoint{URL: "http://root"})
+	ctx := root.Fork().Fork().NewContext()
+	l, err := NamedGet[*namedLogger](ctx, "audit")
+	if err != nil || (*l).prefix != "root" {
+		t.Fatalf("got %v, %v", l, err)
+	}
+	ep, err := Get[*endpoint](ctx)
+	if err != nil || (*ep).URL != "http://root" {
+		t.Fatalf("got %v, %v", ep, err)
+	}
+	// 上下文中的服务容器链中没有时不会使用全局服务容器
+	Bind(&englishGreeter{})
+	if _, err := Get[greeter](ctx); !errors.Is(err, ErrValueNotFound) {
+		t.Fatalf("got %v, want the global binding to be ignored", err)
+	}
+}
+
+func TestBindLazy(t *testing.T) {
+	t.Cleanup(Snapshot())
+	calls := 0
+	BindLazy(func() *endpoint {
+		calls++
+		return &endpoint{URL: "http://lazy"}
+	})
+	if calls != 0 {
+		t.Fatal("fn ran before the first Get")
+	}
+	first := MustGet[*endpoint](context.Background())
+	second := MustGet[*endpoint](context.Background())
+	if calls != 1 || *first != *second || (*first).URL != "http://lazy" {
+		t.Fatalf("fn ran %d times, want 1 with a cached value", calls)
+	}
+}
+
+func TestMustBindStrict(t *testing.T) {
+	t.Cleanup(Snapshot())
+	panics := func(fn func()) (panicked bool) {
+		defer func() { panicked = recover() != nil }()
+		fn()
+		return false
+	}
+	// 非严格模式下重复绑定不会 panic
+	MustBind(&namedLogger{prefix: "a"})
+	if panics(func() { MustBind(&namedLogger{prefix: "b"}) }) {
+		t.Fatal("MustBind panicked outside strict mode")
+	}
+
+	Configure(WithStrict(true))
+	if !panics(func() { MustBind(&namedLogger{prefix: "c"}) }) {
+		t.Fatal("MustBind did not panic on a duplicate in strict mode")
+	}
+	MustNamedBind[greeter]("en", &englishGreeter{})
+	if !panics(func() { MustNamedBind[greeter]("en", &chineseGreeter{}) }) {
+		t.Fatal("MustNamedBind did not panic on a duplicate in strict mode")
+	}
+	if panics(func() { MustNamedBind[greeter]("zh", &chineseGreeter{}) }) {
+		t.Fatal("MustNamedBind panicked for a new name")
+	}
+	// 普通的 Bind 依然静默覆盖
+	if panics(func() { Bind(&namedLogger{prefix: "d"}) }) {
+		t.Fatal("Bind panicked in strict mode")
+	}
+	if v := MustGet[*namedLogger](context.Background()); (*v).prefix != "d" {
+		t.Fatalf("got %q", (*v).prefix)
+	}
+}
+
+func TestMustBindKeysByTypeParameter(t *testing.T) {
+	t.Cleanup(Snapshot())
+	Configure(WithStrict(true))
+	panics := func(fn func()) (panicked bool) {
+		defer func() { panicked = recover() != nil }()
+		fn()
+		return false
+	}
+	// MustBind 与 MustNamedBind 使用相同的键，重复绑定的检查是一致的
+	var g greeter = &englishGreeter{}
+	MustBind(g)
+	if _, ok := global.instances[typeOf[greeter]()][""]; !ok {
+		t.Fatal("MustBind did not key the value by the type parameter")
+	}
+	if !panics(func() { MustNamedBind[greeter]("", &chineseGreeter{}) }) {
+		t.Fatal("MustNamedBind did not detect the value bound by MustBind")
+	}
+	if !panics(func() { MustBind[greeter](&chineseGreeter{}) }) {
+		t.Fatal("MustBind did not detect the duplicate")
+	}
+}
+
+func TestMust(t *testing.T) {
+	c := New()
+	c.Bind(&settings{Level: 2})
+	c.Bind(endpoint{URL: "http://a"})
+	c.Bind(&englishGreeter{})
+	ctx := c.NewContext()
+
+	// 结构体与 Get 一样获取 *T，绑定的指针与值都可以使用
+	if got := Must[settings](ctx); got.Level != 2 {
+		t.Fatalf("got %+v, want the bound *settings", got)
+	}
+	if got := Must[endpoint](ctx); got.URL != "http://a" {
+		t.Fatalf("got %+v, want the bound endpoint", got)
+	}
+	if got := Must[*settings](ctx); got.Level != 2 {
+		t.Fatalf("got %+v", got)
+	}
+	Must[paramC](ctx) // 未绑定的结构体自动构建
+
+	// 接口直接以 T 获取
+	if got := Must[greeter](ctx); got.Greet() != "hello" {
+		t.Fatalf("got %q", got.Greet())
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for an unbound interface")
+		}
+	}()
+	Must[missingDep](ctx)
+}
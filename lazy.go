@@ -0,0 +1,82 @@
+package ioc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Lazy 延迟获取的依赖，注入结构体时不会立即获取其值，而是在首次调用 Get
+// 方法时才从容器中获取并缓存，适用于构建代价高昂且不一定会用到的依赖。
+//
+// 类型为 Lazy[T] 或 *Lazy[T] 的字段会被自动识别，也可以通过 `ioc:",lazy"`
+// 显式地声明，字段 tag 中的名称同样有效。
+type Lazy[T any] struct {
+	once    sync.Once
+	resolve func() (reflect.Value, error)
+	value   T
+	err     error
+}
+
+// Get 获取依赖的值，只有首次调用时才会从容器中获取
+func (l *Lazy[T]) Get() (T, error) {
+	l.once.Do(func() {
+		if l.resolve == nil {
+			l.err = ErrValueNotFound
+			return
+		}
+		val, err := l.resolve()
+		if err != nil {
+			l.err = err
+			return
+		}
+		l.value = valueAs[T](val)
+	})
+	return l.value, l.err
+}
+
+func (l *Lazy[T]) lazyType() reflect.Type {
+	return typeOf[T]()
+}
+
+func (l *Lazy[T]) setResolver(fn func() (reflect.Value, error)) {
+	l.resolve = fn
+}
+
+// lazyValue 由 Lazy[T] 实现，用于在不知道 T 的情况下初始化
+type lazyValue interface {
+	lazyType() reflect.Type
+	setResolver(fn func() (reflect.Value, error))
+}
+
+var lazyValueType = reflect.TypeOf((*lazyValue)(nil)).Elem()
+
+// isLazy 判断类型是否为 Lazy[T] 或 *Lazy[T]
+func isLazy(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && reflect.PointerTo(t).Implements(lazyValueType)
+}
+
+// makeLazy 构建一个类型为 Lazy[T] 或 *Lazy[T] 的值
+func (c *Container) makeLazy(ctx context.Context, name string, t reflect.Type) (reflect.Value, error) {
+	if !isLazy(t) {
+		return reflect.Value{}, fmt.Errorf("ioc: lazy field must be of type Lazy[T] or *Lazy[T], got %v", t)
+	}
+	st := t
+	if st.Kind() == reflect.Pointer {
+		st = st.Elem()
+	}
+	ptr := reflect.New(st)
+	lv := ptr.Interface().(lazyValue)
+	et := lv.lazyType()
+	lv.setResolver(func() (reflect.Value, error) {
+		return c.get(ctx, name, et)
+	})
+	if t.Kind() == reflect.Pointer {
+		return ptr, nil
+	}
+	return ptr.Elem(), nil
+}
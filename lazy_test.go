@@ -0,0 +1,39 @@
+package ioc
+
+import "testing"
+
+func TestLazyField(t *testing.T) {
+	c := New()
+	calls := 0
+	if err := c.NamedFactory("slow", func() *sharedService { calls++; return &sharedService{n: calls} }); err != nil {
+		t.Fatal(err)
+	}
+	var s struct {
+		Tagged *Lazy[*sharedService] `ioc:"slow,lazy"`
+		Typed  Lazy[*sharedService]  `ioc:"slow"`
+	}
+	if err := c.Resolve(&s); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 0 {
+		t.Fatal("lazy fields were constructed during Resolve")
+	}
+	first, err := s.Tagged.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := s.Tagged.Get()
+	if calls != 1 || first != second {
+		t.Fatalf("factory ran %d times, want 1 with a cached value", calls)
+	}
+	if _, err := s.Typed.Get(); err != nil || calls != 2 {
+		t.Fatalf("got %v after %d calls", err, calls)
+	}
+
+	var bad struct {
+		Logger *namedLogger `ioc:",lazy"`
+	}
+	if err := c.Resolve(&bad); err == nil {
+		t.Fatal("expected an error for a lazy field that is not Lazy[T]")
+	}
+}
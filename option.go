@@ -0,0 +1,323 @@
+package ioc
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"time"
+)
+
+// Option 服务容器的配置项
+type Option func(o *options)
+
+// FactoryOption 工厂函数的配置项
+type FactoryOption func(b *binding)
+
+type options struct {
+	// 共享实例（单例）被构建时触发
+	onConstruct func(t reflect.Type, name string, value reflect.Value)
+	// 未指定 tag 的字段使用字段名称作为绑定名称
+	fieldNameAsName bool
+	// 为 func() T 类型的依赖合成调用工厂函数的闭包
+	funcProviders bool
+	// 找不到原语类型的字段时使用零值
+	zeroMissingPrimitives bool
+	// 规范化绑定名称
+	nameResolver func(name string) string
+	// 同一类型与名称下工厂函数优先于绑定的值
+	factoryPrecedence bool
+	// 字段类型的自定义解析函数
+	fieldResolvers map[reflect.Type]FieldResolver
+	// 收集接口的所有实现时不执行工厂函数
+	skipCollectFactories bool
+	// Invoke 执行的函数中未绑定的结构体参数不会被自动构建
+	noInvokeAutoConstruct bool
+	// 请求值类型时可以使用绑定的指针
+	pointerAdaptation bool
+	// 记录绑定的使用情况
+	usageTracking bool
+	// 注入结构体时调用其 setter 方法
+	setterInjection bool
+	// 严格模式，MustBind 等方法在重复绑定时触发 panic
+	strict bool
+	// 注入结构体时忽略无法解析的字段，除非字段使用了 required 标签
+	softResolve bool
+	// 未指定名称时优先使用的绑定名称
+	defaultName string
+	// 找不到具名的绑定时使用匿名的绑定
+	nameFallback bool
+	// 解析的最大嵌套深度，不大于零时使用 defaultMaxDepth
+	maxDepth int
+	// 记录每次解析的调试日志
+	slogger *slog.Logger
+	// 在工厂函数执行前后开始与结束追踪的 span
+	spanStarter SpanStarter
+	// 执行工厂函数时设置 pprof 标签
+	profileLabels bool
+}
+
+// defaultMaxDepth 默认的最大解析深度
+const defaultMaxDepth = 256
+
+// depthLimit 返回解析的最大嵌套深度
+func (o *options) depthLimit() int {
+	if o.maxDepth > 0 {
+		return o.maxDepth
+	}
+	return defaultMaxDepth
+}
+
+// FieldResolver 自定义的字段解析函数
+type FieldResolver func(c *Container, field reflect.StructField) (reflect.Value, error)
+
+// WithOnConstruct 设置共享实例（单例）被构建时触发的钩子函数，
+// 每个共享实例只会触发一次，临时实例的构建与缓存命中均不会触发。
+func WithOnConstruct(fn func(t reflect.Type, name string, value reflect.Value)) Option {
+	return func(o *options) {
+		o.onConstruct = fn
+	}
+}
+
+// WithFieldNameAsName 对于没有指定 tag 的导出字段，注入时优先使用字段名称
+// 作为绑定名称查找“具体实现”，找不到时再使用匿名绑定，默认关闭。
+func WithFieldNameAsName() Option {
+	return func(o *options) {
+		o.fieldNameAsName = true
+	}
+}
+
+// WithFuncProviders 启用函数类型依赖的自动合成：当需要注入的依赖类型形如
+// func() T 或 func() (T, error)，且未绑定该函数类型本身，但 T 注册了工厂函数时，
+// 容器会注入一个闭包，每次调用该闭包都会从容器中获取 T，从而实现延迟构建。
+// 对于 func() T 形式的闭包，获取失败时将触发 panic。
+func WithFuncProviders() Option {
+	return func(o *options) {
+		o.funcProviders = true
+	}
+}
+
+// WithZeroMissingPrimitives 注入结构体时，找不到“具体实现”的原语类型字段
+// （布尔、数字或字符串）将被设置为零值而不是返回错误，结构体、接口等
+// 其它类型的字段不受影响。
+func WithZeroMissingPrimitives() Option {
+	return func(o *options) {
+		o.zeroMissingPrimitives = true
+	}
+}
+
+// WithNameResolver 设置绑定名称的解析器，绑定与查找时都会使用它来规范化名称，
+// 从而将多个别名（如 db、database、primary_db）映射到同一个名称上。
+func WithNameResolver(fn func(name string) string) Option {
+	return func(o *options) {
+		o.nameResolver = fn
+	}
+}
+
+// WithFactoryPrecedence 同一类型与名称同时存在绑定的值与工厂函数时，
+// 优先使用工厂函数，默认优先使用绑定的值。
+func WithFactoryPrecedence() Option {
+	return func(o *options) {
+		o.factoryPrecedence = true
+	}
+}
+
+// WithFieldResolver 为类型 t 的字段设置自定义的解析函数，注入结构体时，
+// 类型为 t 的字段将使用该函数获取值，而不是从容器中查找，适用于从配置
+// 中心读取等派生出来的字段。
+func WithFieldResolver(t reflect.Type, fn FieldResolver) Option {
+	return func(o *options) {
+		resolvers := make(map[reflect.Type]FieldResolver, len(o.fieldResolvers)+1)
+		for k, v := range o.fieldResolvers {
+			resolvers[k] = v
+		}
+		resolvers[t] = fn
+		o.fieldResolvers = resolvers
+	}
+}
+
+// WithCollectFactories 设置收集接口的所有实现（GetAll 以及 []Interface、
+// map[string]Interface 字段的注入）时是否执行工厂函数，默认执行；
+// 设置为 false 时只收集已经构建并缓存的共享实例。
+func WithCollectFactories(enabled bool) Option {
+	return func(o *options) {
+		o.skipCollectFactories = !enabled
+	}
+}
+
+// WithAutoConstructInvokeArgs 设置 Invoke 执行的函数中未绑定的结构体（或结构体指针）
+// 参数是否自动构建，默认自动构建；设置为 false 时这样的参数会返回 ErrValueNotFound。
+// 该配置不影响工厂函数的参数，工厂函数依然可以使用带有 ioc 标签的配置结构体作为参数。
+func WithAutoConstructInvokeArgs(enabled bool) Option {
+	return func(o *options) {
+		o.noInvokeAutoConstruct = !enabled
+	}
+}
+
+// WithPointerAdaptation 请求值类型（如 Config）而容器中只绑定了其指针（如 *Config）时，
+// 解引用该指针并返回其指向的值的副本。反方向的适配（绑定了值而请求其指针）总是启用的，
+// 返回的是绑定的值的可寻址副本的指针。
+func WithPointerAdaptation() Option {
+	return func(o *options) {
+		o.pointerAdaptation = true
+	}
+}
+
+// WithUsageTracking 记录每个绑定是否被获取或注入过，配合 UnusedBindings
+// 找出从未使用过的绑定，用于清理无用的依赖配置。
+func WithUsageTracking() Option {
+	return func(o *options) {
+		o.usageTracking = true
+	}
+}
+
+// WithSetterInjection 注入结构体时，除了字段之外还调用其 setter 方法注入依赖，
+// setter 方法是指针接收者上名称为 Set 后跟大写字母、只有一个参数、没有返回值或者
+// 只返回一个错误的导出方法，如 SetLogger(*Logger)，从嵌入字段提升的方法除外。
+// 参数从容器中匿名获取，找不到时跳过该方法；setter 在所有字段注入完成之后按照
+// 方法名称的顺序调用。
+func WithSetterInjection() Option {
+	return func(o *options) {
+		o.setterInjection = true
+	}
+}
+
+// WithStrict 设置是否启用严格模式，启用后 MustBind 与 MustNamedBind 在同一类型与
+// 名称已经绑定过值或工厂函数时触发 panic，而 Bind 与 NamedBind 依然静默地覆盖。
+func WithStrict(enabled bool) Option {
+	return func(o *options) {
+		o.strict = enabled
+	}
+}
+
+// WithSoftResolve 注入结构体时忽略所有无法解析的字段（保持原值），如同所有字段
+// 都使用了 omitempty 标签，使用 `ioc:",required"` 标签的字段除外。
+func WithSoftResolve() Option {
+	return func(o *options) {
+		o.softResolve = true
+	}
+}
+
+// WithDefaultName 设置默认的绑定名称，获取值时若没有指定名称（如字段的 tag 中没有
+// 名称、Get 或 NamedGet 传入空字符串），则优先使用以该名称绑定（包括父容器中）的值，
+// 找不到时再使用匿名绑定；显式指定的名称不受影响。绑定时不使用默认名称。
+//
+// 适用于多租户的场景，如为每个租户派生一个子容器：
+//
+//	tenant := root.Fork(ioc.WithDefaultName("tenant-a"))
+func WithDefaultName(name string) Option {
+	return func(o *options) {
+		o.defaultName = name
+	}
+}
+
+// WithNameFallback 获取具名的值（如字段使用了 `ioc:"primary"` 标签）而容器中
+// 没有以该名称绑定的值或工厂函数时，使用匿名绑定的值或工厂函数，默认不启用。
+func WithNameFallback() Option {
+	return func(o *options) {
+		o.nameFallback = true
+	}
+}
+
+// WithMaxDepth 设置解析的最大嵌套深度，超过时返回 ErrMaxDepthExceeded，
+// 默认为 256，n 不大于零时使用默认值。
+func WithMaxDepth(n int) Option {
+	return func(o *options) {
+		o.maxDepth = n
+	}
+}
+
+// WithSlogger 设置记录解析过程的日志器，每次解析都会以 debug 级别记录
+// 类型（type）、名称（name）、来源（source）与耗时（duration），解析失败时
+// 还会记录错误（error），来源为 instance、factory、scan、parent、context、
+// default、provider 或 construct 之一，默认不记录日志。
+func WithSlogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.slogger = logger
+	}
+}
+
+// SpanStarter 在工厂函数执行前被调用，参数 typeName 为工厂函数返回值的类型名称，
+// 返回的上下文将被传入工厂函数，返回的函数在工厂函数执行完成后被调用，其参数为
+// 构建失败时的错误。
+type SpanStarter func(ctx context.Context, typeName string) (context.Context, func(error))
+
+// WithSpanStarter 设置在工厂函数执行前后调用的追踪钩子，可以用于接入 OpenTelemetry
+// 等分布式追踪系统而无需本包依赖它们，每次执行工厂函数（包括共享实例的首次构建）
+// 都对应一个 span，通过 RegisterProvider 注册的类型化构造函数也不例外。
+func WithSpanStarter(start SpanStarter) Option {
+	return func(o *options) {
+		o.spanStarter = start
+	}
+}
+
+// WithProfileLabels 执行工厂函数时通过 pprof.Do 为当前 goroutine 设置标签
+// ioc.type，其值为工厂函数返回值的类型名称，以便在 CPU 剖析中将耗时归属到
+// 具体的工厂函数，传入工厂函数的上下文同样带有该标签，默认关闭。
+func WithProfileLabels() Option {
+	return func(o *options) {
+		o.profileLabels = true
+	}
+}
+
+// Shared 工厂函数构建的实例是共享的（单例），只会构建一次
+func Shared() FactoryOption {
+	return func(b *binding) {
+		b.shared = true
+	}
+}
+
+// WithTTL 工厂函数构建的实例是共享的，但在构建 d 时长之后过期，
+// 过期后的下一次获取将重新执行工厂函数；过期的实例若实现了 io.Closer
+// 接口，则在被替换时立即关闭，此后仍持有它的调用方不应再使用它。
+func WithTTL(d time.Duration) FactoryOption {
+	return func(b *binding) {
+		b.shared = true
+		b.ttl = d
+	}
+}
+
+// WithFactoryTimeout 限制工厂函数单次执行的时长，工厂函数（及其依赖的解析）
+// 使用的上下文会在 d 之后取消，超时之后返回包装了 context.DeadlineExceeded 的错误。
+//
+// 只有接收 context.Context 参数并响应取消的工厂函数才会被及时中断，
+// 不响应取消的工厂函数会执行完毕，但其结果依然被视为超时而丢弃，实现了
+// io.Closer 接口的结果会被立即关闭。解析所使用的上下文本身的截止时间先到期时
+// 不视为工厂函数超时，返回的是工厂函数自身的结果。
+func WithFactoryTimeout(d time.Duration) FactoryOption {
+	return func(b *binding) {
+		b.timeout = d
+	}
+}
+
+// WithClosePriority 设置工厂函数构建的共享实例在容器关闭时的优先级，
+// 优先级越高的越先关闭，默认为 0，优先级相同的按照与构建相反的顺序关闭。
+func WithClosePriority(priority int) FactoryOption {
+	return func(b *binding) {
+		b.closePriority = priority
+	}
+}
+
+// WithFinalizer 为工厂函数构建的、实现了 io.Closer 接口的非共享指针实例设置
+// 终结器（runtime.SetFinalizer），在实例被垃圾回收时调用其 Close 方法。
+//
+// 这只是一种尽力而为的兜底措施：终结器的执行时机是不确定的，程序退出时也可能
+// 根本不会执行，Close 返回的错误会被忽略，实例已有的终结器也会被替换。需要
+// 确定性地释放资源时，应当使用共享实例并调用容器的 Close 方法。
+//
+// 工厂函数返回的指针必须指向一次内存分配的起始位置（如 &T{} 或 new(T)），
+// 而不能是结构体字段或切片元素的地址（如 &parent.field、&items[i]），否则
+// runtime.SetFinalizer 会产生无法恢复的致命错误，由于容器无法检查这一点，
+// 只能由工厂函数的注册者逐个启用。
+func WithFinalizer() FactoryOption {
+	return func(b *binding) {
+		b.finalizer = true
+	}
+}
+
+// WithInterfaceOnly 工厂函数构建的实例只能通过其实现的接口获取（包括注入与收集），
+// 直接获取工厂函数的返回值类型时视为找不到，用于隐藏具体的实现类型。
+func WithInterfaceOnly() FactoryOption {
+	return func(b *binding) {
+		b.ifaceOnly = true
+	}
+}
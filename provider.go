@@ -0,0 +1,38 @@
+package ioc
+
+import "reflect"
+
+// RegisterProvider 为全局服务容器注册类型 T 的类型化构造函数，构造函数接收执行它的
+// 服务容器，每次获取时都会执行（不共享）。与 Factory 不同，构造函数不通过反射调用，
+// 适用于对性能敏感或者代码生成的场景。构造函数与工厂函数一样参与查找与注入，
+// 同样经过追踪钩子、时长限制、pprof 标签、嵌套深度限制与统计，子容器中绑定的 *T
+// 或 T、默认名称以及与上下文匹配的绑定等依然优先于构造函数。
+func RegisterProvider[T any](fn func(*Container) (T, error)) {
+	global.setProvider(typeOf[T](), func(c *Container) (reflect.Value, error) {
+		v, err := fn(c)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(&v).Elem(), nil
+	})
+}
+
+// setProvider 以匿名的方式注册类型 t 的类型化构造函数
+func (c *Container) setProvider(t reflect.Type, provider func(c *Container) (reflect.Value, error)) {
+	name := c.canonicalName("")
+	if c.factories == nil {
+		c.factories = make(map[reflect.Type]map[string]*binding)
+	}
+	if _, ok := c.factories[t]; !ok {
+		c.factories[t] = make(map[string]*binding)
+	}
+	c.factories[t][name] = &binding{
+		name: name,
+		typ:  t,
+		// 构造函数接收的服务容器不是依赖，使用不带参数的签名用于依赖分析与错误信息，
+		// 该函数不会被调用
+		factory:  reflect.Zero(reflect.FuncOf(nil, []reflect.Type{t, errorType}, false)),
+		provider: provider,
+	}
+	c.touch()
+}
@@ -0,0 +1,179 @@
+package ioc
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type providedService struct{ N int }
+
+func TestRegisterProvider(t *testing.T) {
+	t.Cleanup(Snapshot())
+	calls := 0
+	RegisterProvider(func(c *Container) (providedService, error) {
+		calls++
+		return providedService{N: 1}, nil
+	})
+	v, err := Get[providedService](context.Background())
+	if err != nil || v.N != 1 {
+		t.Fatalf("got %v, %v", v, err)
+	}
+	// 通过反射的注入同样使用构造函数
+	var s struct {
+		Service providedService
+	}
+	if err := Resolve(&s); err != nil || s.Service.N != 1 {
+		t.Fatalf("got %+v, %v", s.Service, err)
+	}
+	if calls != 2 {
+		t.Fatalf("provider ran %d times, want 2", calls)
+	}
+}
+
+func TestRegisterProviderPrecedence(t *testing.T) {
+	t.Cleanup(Snapshot())
+	RegisterProvider(func(c *Container) (providedService, error) {
+		return providedService{N: 1}, nil
+	})
+	get := func(c *Container, ctx context.Context) int {
+		t.Helper()
+		if ctx == nil {
+			ctx = c.NewContext()
+		}
+		v, err := Get[providedService](ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 类型化的获取与反射的获取结果一致
+		rv, err := c.GetContext(ctx, typeOf[*providedService]())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := rv.Interface().(*providedService).N; n != v.N {
+			t.Fatalf("typed path got %d, reflect path got %d", v.N, n)
+		}
+		return v.N
+	}
+
+	// 子容器中绑定的 *T 优先于全局的构造函数
+	child := global.Fork()
+	child.Bind(&providedService{N: 2})
+	if n := get(child, nil); n != 2 {
+		t.Fatalf("got %d, want the child's *providedService", n)
+	}
+	// 子容器中绑定的 T 同样优先
+	child = global.Fork()
+	child.Bind(providedService{N: 3})
+	if n := get(child, nil); n != 3 {
+		t.Fatalf("got %d, want the child's providedService", n)
+	}
+	// 默认名称
+	child = global.Fork(WithDefaultName("tenant"))
+	child.NamedBind("tenant", &providedService{N: 4})
+	if n := get(child, nil); n != 4 {
+		t.Fatalf("got %d, want the default-named binding", n)
+	}
+	// 与上下文匹配的绑定
+	child = global.Fork()
+	child.BindForContext(tenantKey{}, func(v any) bool { return v == "acme" }, &providedService{N: 5})
+	ctx := context.WithValue(child.NewContext(), tenantKey{}, "acme")
+	if n := get(child, ctx); n != 5 {
+		t.Fatalf("got %d, want the context binding", n)
+	}
+	// 没有其它绑定时使用构造函数，并记录使用情况
+	child = global.Fork(WithUsageTracking())
+	if n := get(child, nil); n != 1 {
+		t.Fatalf("got %d, want the provider", n)
+	}
+	Configure(WithUsageTracking())
+	if _, err := Get[providedService](context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range global.UnusedBindings() {
+		if info.Type == typeOf[providedService]() {
+			t.Fatal("the typed path did not record the provider as used")
+		}
+	}
+}
+
+func TestRegisterProviderHooks(t *testing.T) {
+	t.Cleanup(Snapshot())
+	var spans []string
+	Configure(WithSpanStarter(func(ctx context.Context, typeName string) (context.Context, func(error)) {
+		spans = append(spans, typeName)
+		return ctx, func(error) {}
+	}))
+	RegisterProvider(func(c *Container) (providedService, error) {
+		return providedService{N: 1}, nil
+	})
+	_, misses := global.CacheStats()
+	if v, err := Get[providedService](context.Background()); err != nil || v.N != 1 {
+		t.Fatalf("got %v, %v", v, err)
+	}
+	if len(spans) != 1 || spans[0] != "ioc.providedService" {
+		t.Fatalf("got spans %v, want the provider traced like a factory", spans)
+	}
+	if _, got := global.CacheStats(); got != misses+1 {
+		t.Fatalf("got %d misses, want %d", got, misses+1)
+	}
+	// 构造函数接收的服务容器不是依赖
+	if dot := global.GraphDOT(); strings.Contains(dot, "Container") {
+		t.Fatalf("got %s, want no dependency on the container", dot)
+	}
+	for _, u := range global.HealthCheck() {
+		if u.Type == typeOf[providedService]() {
+			t.Fatalf("got %+v, want the provider to be satisfiable", u)
+		}
+	}
+	// 全局容器中与上下文匹配的 *T 优先于构造函数，类型化与反射的获取一致
+	global.BindForContext(tenantKey{}, func(v any) bool { return v == "acme" }, &providedService{N: 5})
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	v, err := Get[providedService](ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rv, err := global.GetContext(ctx, typeOf[*providedService]())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := rv.Interface().(*providedService).N; v.N != 5 || n != 5 {
+		t.Fatalf("typed path got %d, reflect path got %d, want the context binding", v.N, n)
+	}
+}
+
+func BenchmarkRegisterProvider(b *testing.B) {
+	b.Cleanup(Snapshot())
+	RegisterProvider(func(c *Container) (providedService, error) {
+		return providedService{N: 1}, nil
+	})
+	ctx := context.Background()
+	b.Run("typed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := Get[providedService](ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("provider-reflect", func(b *testing.B) {
+		typ := reflect.TypeOf(&providedService{})
+		for i := 0; i < b.N; i++ {
+			if _, err := global.GetContext(ctx, typ); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("factory-reflect", func(b *testing.B) {
+		c := New()
+		if err := c.Factory(func() providedService { return providedService{N: 1} }); err != nil {
+			b.Fatal(err)
+		}
+		typ := reflect.TypeOf(&providedService{})
+		for i := 0; i < b.N; i++ {
+			if _, err := c.GetContext(ctx, typ); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
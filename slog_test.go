@@ -0,0 +1,66 @@
+package ioc
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+// captureHandler 记录所有日志及其属性
+type captureHandler struct {
+	records *[]map[string]any
+}
+
+func (h captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h captureHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := map[string]any{"msg": r.Message, "level": r.Level}
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.Any()
+		return true
+	})
+	*h.records = append(*h.records, attrs)
+	return nil
+}
+
+func (h captureHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h captureHandler) WithGroup(string) slog.Handler      { return h }
+
+func TestSlogger(t *testing.T) {
+	var records []map[string]any
+	c := New(WithSlogger(slog.New(captureHandler{&records})))
+	c.NamedBind("primary", &endpoint{URL: "http://a"})
+	if err := c.Factory(func() *database { return &database{} }); err != nil {
+		t.Fatal(err)
+	}
+	mustGet(t, c, "primary", typeOf[*endpoint]())
+	mustGet(t, c, "", typeOf[*database]())
+	if _, err := c.Get(typeOf[missingDep]()); err == nil {
+		t.Fatal("want an error for a missing dependency")
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3: %v", len(records), records)
+	}
+	want := []map[string]any{
+		{"type": "*ioc.endpoint", "name": "primary", "source": "instance"},
+		{"type": "*ioc.database", "name": "", "source": "factory"},
+		{"type": "ioc.missingDep", "name": ""},
+	}
+	for i, w := range want {
+		r := records[i]
+		if r["level"] != slog.LevelDebug {
+			t.Errorf("record %d: got level %v", i, r["level"])
+		}
+		if _, ok := r["duration"]; !ok {
+			t.Errorf("record %d: missing duration", i)
+		}
+		for k, v := range w {
+			if r[k] != v {
+				t.Errorf("record %d: got %s=%v, want %v", i, k, r[k], v)
+			}
+		}
+	}
+	if _, ok := records[2]["error"]; !ok {
+		t.Error("the failed resolution did not log its error")
+	}
+}
@@ -0,0 +1,81 @@
+package ioc
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// Snapshot 保存全局容器当前的绑定，返回一个恢复函数，调用它会将全局容器
+// 恢复到保存时的状态，主要用于避免测试之间通过全局容器相互影响：
+//
+//	t.Cleanup(ioc.Snapshot())
+func Snapshot() func() {
+	saved := global.clone()
+	return func() {
+		global.restore(saved)
+	}
+}
+
+// clone 复制容器的绑定以及需要建立索引的接口与绑定的使用记录，工厂函数的
+// 绑定也会被复制，因此之后构建的共享实例不会影响复制出来的容器。
+func (c *Container) clone() *Container {
+	cc := &Container{
+		opts:     c.opts,
+		parents:  slices.Clone(c.parents),
+		contexts: slices.Clone(c.contexts),
+		closers:  slices.Clone(c.closers),
+		indexed:  slices.Clone(c.indexed),
+		used:     maps.Clone(c.used),
+		aliases:  maps.Clone(c.aliases),
+		defaults: maps.Clone(c.defaults),
+	}
+	if c.instances != nil {
+		cc.instances = make(map[reflect.Type]map[string]reflect.Value, len(c.instances))
+		for t, values := range c.instances {
+			cc.instances[t] = maps.Clone(values)
+		}
+	}
+	if c.groups != nil {
+		cc.groups = make(map[string][]groupMember, len(c.groups))
+		for group, members := range c.groups {
+			cc.groups[group] = make([]groupMember, len(members))
+			for i, m := range members {
+				if m.factory != nil {
+					copied := *m.factory
+					m.factory = &copied
+				}
+				cc.groups[group][i] = m
+			}
+		}
+	}
+	if c.factories != nil {
+		cc.factories = make(map[reflect.Type]map[string]*binding, len(c.factories))
+		for t, bindings := range c.factories {
+			cc.factories[t] = make(map[string]*binding, len(bindings))
+			for name, b := range bindings {
+				copied := *b
+				cc.factories[t][name] = &copied
+			}
+		}
+	}
+	return cc
+}
+
+// restore 将容器的绑定恢复为 saved 中的绑定
+func (c *Container) restore(saved *Container) {
+	saved = saved.clone()
+	c.opts = saved.opts
+	c.parents = saved.parents
+	c.factories = saved.factories
+	c.instances = saved.instances
+	c.aliases = saved.aliases
+	c.contexts = saved.contexts
+	c.defaults = saved.defaults
+	c.groups = saved.groups
+	c.closers = saved.closers
+	c.indexed = saved.indexed
+	c.used = saved.used
+	c.cache = nil
+	c.touch()
+}
@@ -0,0 +1,76 @@
+package ioc
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	t.Cleanup(Snapshot())
+	restore := Snapshot()
+	Bind(&namedLogger{prefix: "temporary"})
+	if err := Factory(func() *sharedService { return &sharedService{} }, true); err != nil {
+		t.Fatal(err)
+	}
+	restore()
+	if _, ok := global.instances[typeOf[*namedLogger]()][""]; ok {
+		t.Fatal("binding made after the snapshot survived restore")
+	}
+	if _, ok := global.factories[typeOf[*sharedService]()][""]; ok {
+		t.Fatal("factory registered after the snapshot survived restore")
+	}
+}
+
+func TestSnapshotCopiesSharedInstances(t *testing.T) {
+	t.Cleanup(Snapshot())
+	calls := 0
+	if err := Factory(func() *sharedService { calls++; return &sharedService{n: calls} }, true); err != nil {
+		t.Fatal(err)
+	}
+	restore := Snapshot()
+	first, err := Get[*sharedService](context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	restore()
+	// 快照之后构建的共享实例不会保留
+	second, err := Get[*sharedService](context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *first == *second || calls != 2 {
+		t.Fatal("shared instance built after the snapshot survived restore")
+	}
+}
+
+func TestSnapshotRestoresIndexAndUsage(t *testing.T) {
+	c := New(WithUsageTracking())
+	c.Bind(&englishGreeter{})
+	c.NamedBind("zh", &chineseGreeter{})
+	saved := c.clone()
+	c.IndexInterfaces((*greeter)(nil))
+	mustGet(t, c, "", typeOf[*englishGreeter]())
+	c.restore(saved)
+	if len(c.indexed) != 0 {
+		t.Fatal("index added after the snapshot survived restore")
+	}
+	if got := c.UnusedBindings(); len(got) != 2 {
+		t.Fatalf("got %v, want usage recorded after the snapshot discarded", got)
+	}
+
+	// 快照之前的索引与使用记录会被保留
+	c.IndexInterfaces((*greeter)(nil))
+	mustGet(t, c, "", typeOf[*englishGreeter]())
+	saved = c.clone()
+	mustGet(t, c, "zh", typeOf[*chineseGreeter]())
+	c.restore(saved)
+	if len(c.indexed) != 1 {
+		t.Fatal("index added before the snapshot was lost")
+	}
+	if got := c.UnusedBindings(); len(got) != 1 || got[0].Name != "zh" {
+		t.Fatalf("got %v, want only the binding used after the snapshot unused", got)
+	}
+	if v := mustGet(t, c, "zh", typeOf[greeter]()); v.Interface().(greeter).Greet() != "你好" {
+		t.Fatalf("got %v through the restored index", v)
+	}
+}
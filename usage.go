@@ -0,0 +1,33 @@
+package ioc
+
+import "reflect"
+
+// usageKey 标识一个绑定
+type usageKey struct {
+	typ  reflect.Type
+	name string
+}
+
+// markUsed 启用了使用情况跟踪时，记录类型 t 与名称 name 的绑定被使用过
+func (c *Container) markUsed(t reflect.Type, name string) {
+	if !c.opts.usageTracking {
+		return
+	}
+	if c.used == nil {
+		c.used = make(map[usageKey]bool)
+	}
+	c.used[usageKey{t, name}] = true
+}
+
+// UnusedBindings 返回本容器中从未被获取或注入过的绑定，顺序与 BindingsImplementing
+// 相同，需要使用配置项 WithUsageTracking 创建容器，否则返回本容器中所有的绑定。
+// 通过子容器获取父容器中的绑定同样视为使用过。
+func (c *Container) UnusedBindings() []BindingInfo {
+	var result []BindingInfo
+	for _, info := range c.bindings() {
+		if !c.used[usageKey{info.Type, info.Name}] {
+			result = append(result, info)
+		}
+	}
+	return result
+}
@@ -0,0 +1,35 @@
+package ioc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnusedBindings(t *testing.T) {
+	c := New(WithUsageTracking())
+	c.Bind(&endpoint{URL: "http://a"})
+	c.NamedBind("level", 3)
+	if err := c.Factory(func() *sharedService { return &sharedService{} }, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(typeOf[*sharedService]()); err != nil {
+		t.Fatal(err)
+	}
+	want := []BindingInfo{
+		{Type: typeOf[*endpoint]()},
+		{Type: typeOf[int](), Name: "level"},
+	}
+	got := c.UnusedBindings()
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for _, w := range want {
+		found := false
+		for _, g := range got {
+			found = found || reflect.DeepEqual(g, w)
+		}
+		if !found {
+			t.Fatalf("%v was not reported unused in %v", w, got)
+		}
+	}
+}
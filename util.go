@@ -1,7 +1,9 @@
 package ioc
 
 import (
+	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 )
 
@@ -18,17 +20,146 @@ func InterfaceOf(value any) reflect.Type {
 	return t
 }
 
-func parseTag(field reflect.StructField) (name string, omitempty, inject bool) {
-	if name, inject = field.Tag.Lookup(tagName); inject {
-		segments := strings.Split(name, ",")
-		for i := 0; i < len(segments); i++ {
-			if i == 0 {
-				name = segments[0]
-			} else if segments[i] == "omitempty" {
-				omitempty = true
-				break
+// fieldTag 解析后的字段 tag，格式为 `ioc:"name,option..."`
+type fieldTag struct {
+	name         string // 绑定的名称
+	inject       bool   // 是否指定了 tag
+	omitempty    bool   // 找不到“具体实现”时忽略该字段，也可以写作 optional
+	fresh        bool   // 忽略共享实例缓存，总是执行工厂函数构建新的实例
+	lazy         bool   // 延迟到首次使用时才获取值
+	resolvedName bool   // 注入结构体被获取时所使用的名称，而不是从容器中获取值
+	required     bool   // 即使启用了 WithSoftResolve，找不到“具体实现”时依然返回错误
+}
+
+func parseTag(field reflect.StructField) (tag fieldTag) {
+	var value string
+	if value, tag.inject = field.Tag.Lookup(tagName); tag.inject {
+		segments := strings.Split(value, ",")
+		tag.name = segments[0]
+		for _, option := range segments[1:] {
+			switch option {
+			case "omitempty", "optional":
+				tag.omitempty = true
+			case "fresh":
+				tag.fresh = true
+			case "lazy":
+				tag.lazy = true
+			case "name":
+				tag.resolvedName = true
+			case "required":
+				tag.required = true
 			}
 		}
 	}
 	return
 }
+
+// isCollection 判断类型是否为可以收集的切片或以字符串为键的映射，元素需要是
+// 接口、结构体或结构体指针，如 []io.Closer、[]Service 与 map[string]*Service
+func isCollection(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice:
+		return isCollectable(t.Elem())
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && isCollectable(t.Elem())
+	default:
+		return false
+	}
+}
+
+// isCollectable 判断类型是否可以作为收集的元素类型
+func isCollectable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Struct:
+		return true
+	case reflect.Pointer:
+		return t.Elem().Kind() == reflect.Struct
+	default:
+		return false
+	}
+}
+
+// sortTypes 按照包路径与类型名称对类型排序
+func sortTypes(types []reflect.Type) {
+	sort.Slice(types, func(i, j int) bool {
+		if types[i].PkgPath() != types[j].PkgPath() {
+			return types[i].PkgPath() < types[j].PkgPath()
+		}
+		return types[i].String() < types[j].String()
+	})
+}
+
+// sameValue 判断两个绑定的值是否为同一个值，如 BindWithInterfaces 以实际类型与
+// 各个接口分别绑定的同一个值；不可比较的值（如函数）只有来自同一次绑定时才相同。
+func sameValue(a, b reflect.Value) bool {
+	if a == b { // 同一次绑定保存的 reflect.Value 完全相同
+		return true
+	}
+	return a.IsValid() && b.IsValid() && a.Type() == b.Type() &&
+		a.Comparable() && b.Comparable() && a.Equal(b)
+}
+
+// sortedKeys 返回排序后的映射键
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// isNil 判断值是否为 nil，对于不能为 nil 的类型始终返回 false
+func isNil(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map,
+		reflect.Pointer, reflect.Slice, reflect.UnsafePointer:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
+
+// concreteType 返回值的实际类型，对于非 nil 的接口值返回其动态类型
+func concreteType(v reflect.Value) reflect.Type {
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		return v.Elem().Type()
+	}
+	return v.Type()
+}
+
+// isPrimitive 判断类型是否为原语类型（布尔、数字或字符串）
+func isPrimitive(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	default:
+		return false
+	}
+}
+
+// mustNotNil 值为 nil 时触发 panic，参数 t 用于在错误信息中表示值的类型
+func mustNotNil(t reflect.Type, value any) {
+	if isNil(reflect.ValueOf(value)) {
+		panic(fmt.Sprintf("ioc: cannot bind nil value of type %v", t))
+	}
+}
+
+// typeOf 返回类型参数 T 对应的反射类型，T 可以是接口
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// valueAs 将反射值转换为类型 T，值无效时返回 T 的零值
+func valueAs[T any](v reflect.Value) T {
+	var t T
+	if v.IsValid() {
+		reflect.ValueOf(&t).Elem().Set(v)
+	}
+	return t
+}